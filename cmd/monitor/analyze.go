@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/srt32/copilot-actions-looper/pkg/github"
+)
+
+// runAnalyze runs the log analysis of failed jobs over a saved job log, for
+// tuning extraction without a failing run:
+//
+//	monitor analyze [flags] <logfile|->
+//
+// Settings start from the same MONITOR_* environment variables as a run,
+// and the flags override them.
+func runAnalyze(args []string) {
+	config := loadConfig()
+	fs := flag.NewFlagSet("analyze", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: monitor analyze [flags] <logfile|->\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "text", "output format: text or json")
+	keywords := fs.String("keywords", strings.Join(config.ErrorKeywords, ","),
+		"comma-separated words that mark an error line (default the built-in ones)")
+	lines := fs.Int("snippet-lines", config.SnippetLines, "log lines kept in the error snippet")
+	parsers := fs.String("parsers", strings.Join(config.Parsers, ","),
+		"comma-separated parsers to run (default all): "+strings.Join(github.DefaultRegistry().Names(), ", "))
+	matchers := fs.String("problem-matchers", os.Getenv("MONITOR_PROBLEM_MATCHERS"),
+		"comma-separated problem matcher files or globs")
+	baselineFile := fs.String("baseline", "", "log of a passing run of the job, to show only new lines in the snippet")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 || (*format != "text" && *format != "json") {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	config.ErrorKeywords = splitList(*keywords)
+	config.SnippetLines = *lines
+	config.Parsers = splitList(*parsers)
+	if _, err := github.DefaultRegistry().Select(config.Parsers); err != nil {
+		log.Fatalf("Invalid -parsers: %v", err)
+	}
+	client := github.NewClientWithConfig("", "", config)
+	registerProblemMatchers(client, splitList(*matchers))
+
+	var baseline github.LogBaseline
+	if *baselineFile != "" {
+		f, err := os.Open(*baselineFile)
+		if err != nil {
+			log.Fatalf("Failed to open baseline log: %v", err)
+		}
+		baseline, err = github.NewLogBaseline(f)
+		f.Close()
+		if err != nil {
+			log.Fatalf("Failed to read baseline log: %v", err)
+		}
+	}
+
+	logs := io.Reader(os.Stdin)
+	if name := fs.Arg(0); name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Fatalf("Failed to open log: %v", err)
+		}
+		defer f.Close()
+		logs = f
+	}
+
+	analysis, err := client.AnalyzeLog(logs, baseline)
+	if err != nil {
+		log.Fatalf("Failed to read log: %v", err)
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(analysis); err != nil {
+			log.Fatalf("Failed to write analysis: %v", err)
+		}
+		return
+	}
+	printAnalysis(os.Stdout, analysis)
+}
+
+// printAnalysis writes a log analysis for reading in a terminal
+func printAnalysis(w io.Writer, analysis github.LogAnalysis) {
+	if len(analysis.Failures) == 0 {
+		fmt.Fprintf(w, "No parser recognized a failure.\n\nSnippet:\n%s\n", analysis.Snippet)
+	} else {
+		fmt.Fprintf(w, "%d failure(s) found by %s:\n", len(analysis.Failures), strings.Join(analysis.Parsers, ", "))
+		for _, f := range analysis.Failures {
+			fmt.Fprintf(w, "\n[%s]", f.Parser)
+			if f.TestID != "" {
+				fmt.Fprintf(w, " %s", f.TestID)
+			}
+			if f.File != "" {
+				location := f.File
+				if f.Line > 0 {
+					location += fmt.Sprintf(":%d", f.Line)
+				}
+				if f.Column > 0 {
+					location += fmt.Sprintf(":%d", f.Column)
+				}
+				fmt.Fprintf(w, " at %s", location)
+			}
+			fmt.Fprintln(w)
+			for _, text := range []string{f.Message, f.Stack} {
+				if text != "" {
+					fmt.Fprintf(w, "    %s\n", strings.ReplaceAll(text, "\n", "\n    "))
+				}
+			}
+		}
+	}
+
+	var steps []string
+	for i, line := range analysis.StepErrorLines {
+		if line > 0 {
+			steps = append(steps, fmt.Sprintf("section %d, line %d", i, line))
+		}
+	}
+	if len(steps) > 0 {
+		fmt.Fprintf(w, "\nFirst error line of each step: %s\n", strings.Join(steps, "; "))
+	}
+}
@@ -1,15 +1,55 @@
 package main
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/srt32/copilot-actions-looper/pkg/github"
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "analyze":
+			runAnalyze(os.Args[2:])
+			return
+		case "render":
+			runRender(os.Args[2:])
+			return
+		}
+	}
+
+	logFormat := flag.String("log-format", envString("MONITOR_LOG_FORMAT", defaultLogFormat()),
+		"log output: text, json, or actions for GitHub Actions workflow commands")
+	logLevel := flag.String("log-level", envString("MONITOR_LOG_LEVEL", defaultLogLevel()),
+		"lowest level logged: debug, info, warn or error")
+	dryRun := flag.Bool("dry-run", envBool("MONITOR_DRY_RUN", false),
+		"render comments, reviews and check runs but write nothing to GitHub")
+	flag.Parse()
+
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		log.Fatalf("Invalid log level %q", *logLevel)
+	}
+	logger, err := github.NewLogger(os.Stdout, *logFormat, level)
+	if err != nil {
+		log.Fatalf("Invalid log format: %v", err)
+	}
+	slog.SetDefault(logger)
+	// Fatal errors reported through the log package are logged as errors
+	slog.SetLogLoggerLevel(slog.LevelError)
+
 	token := os.Getenv("GITHUB_TOKEN")
 	if token == "" {
 		log.Fatal("GITHUB_TOKEN environment variable is required")
@@ -18,51 +58,269 @@ func main() {
 	eventName := os.Getenv("GITHUB_EVENT_NAME")
 	eventPath := os.Getenv("GITHUB_EVENT_PATH")
 	repository := os.Getenv("GITHUB_REPOSITORY")
-	runID := os.Getenv("GITHUB_RUN_ID")
 
-	fmt.Printf("=== GitHub Actions Monitor Starting ===\n")
-	fmt.Printf("Repository: %s\n", repository)
-	fmt.Printf("Event Type: %s\n", eventName)
-	fmt.Printf("Event Path: %s\n", eventPath)
-	fmt.Printf("Run ID: %s\n", runID)
-	fmt.Printf("=====================================\n\n")
+	logger.Info("Monitor starting",
+		"repo", repository,
+		"event", eventName,
+		"event_path", eventPath,
+		"monitor_run_id", os.Getenv("GITHUB_RUN_ID"))
 
 	if eventPath == "" {
 		log.Fatal("GITHUB_EVENT_PATH environment variable is required")
 	}
 
-	fmt.Printf("Reading event data from: %s\n", eventPath)
 	eventData, err := os.ReadFile(eventPath)
 	if err != nil {
 		log.Fatalf("Failed to read event file: %v", err)
 	}
-	fmt.Printf("Successfully read %d bytes of event data\n\n", len(eventData))
+	logger.Debug("Read event data", "bytes", len(eventData))
 
-	client := github.NewClient(token, repository)
+	config := loadConfig()
+	config.Logger = logger
+	config.DryRun = *dryRun
+	client := github.NewClientWithConfig(token, repository, config)
+
+	registerProblemMatchers(client, splitList(os.Getenv("MONITOR_PROBLEM_MATCHERS")))
 
 	switch eventName {
 	case "workflow_run":
-		fmt.Printf("Processing workflow_run event...\n")
 		var event github.WorkflowRunEvent
 		if err := json.Unmarshal(eventData, &event); err != nil {
 			log.Fatalf("Failed to parse workflow_run event: %v", err)
 		}
-		fmt.Printf("Event action: %s\n", event.Action)
-		if err := client.HandleWorkflowRun(&event); err != nil {
+		err := client.HandleWorkflowRun(&event)
+		writeActionsSummary(client.Summary())
+		if *dryRun {
+			printComments(client.Summary(), *logFormat == github.LogFormatActions)
+		}
+		if err != nil {
 			log.Fatalf("Failed to handle workflow_run event: %v", err)
 		}
 	case "pull_request":
-		fmt.Printf("Processing pull_request event...\n")
 		var event github.PullRequestEvent
 		if err := json.Unmarshal(eventData, &event); err != nil {
 			log.Fatalf("Failed to parse pull_request event: %v", err)
 		}
-		fmt.Printf("Event action: %s\n", event.Action)
-		if err := client.HandlePullRequest(&event); err != nil {
+		err := client.HandlePullRequest(&event)
+		writeActionsSummary(client.Summary())
+		if *dryRun {
+			printComments(client.Summary(), *logFormat == github.LogFormatActions)
+		}
+		if err != nil {
 			log.Fatalf("Failed to handle pull_request event: %v", err)
 		}
 	default:
-		fmt.Printf("Ignoring event type: %s\n", eventName)
+		logger.Info("Ignoring event", "event", eventName)
+	}
+	logger.Info("Monitor completed")
+}
+
+// registerProblemMatchers adds the problem matchers in the given files or
+// globs to the extractors of a client
+func registerProblemMatchers(client *github.Client, paths []string) {
+	if len(paths) == 0 {
+		return
+	}
+	matchers, err := github.LoadProblemMatchers(paths)
+	if err != nil {
+		log.Fatalf("Failed to load problem matchers: %v", err)
+	}
+	for _, m := range matchers {
+		slog.Debug("Registered problem matcher", "matcher", m.Name())
+		client.RegisterExtractor(m)
+	}
+}
+
+// defaultLogFormat writes workflow commands when running in GitHub Actions,
+// and text otherwise
+func defaultLogFormat() string {
+	if os.Getenv("GITHUB_ACTIONS") == "true" {
+		return github.LogFormatActions
+	}
+	return github.LogFormatText
+}
+
+// defaultLogLevel includes debug logs when the run has debug logging enabled
+func defaultLogLevel() string {
+	if os.Getenv("RUNNER_DEBUG") == "1" {
+		return "debug"
+	}
+	return "info"
+}
+
+// writeActionsSummary appends what the run did to the job summary, and its
+// key results to the step outputs, when running in GitHub Actions. Failing
+// to write them only costs the report, so errors are warnings.
+func writeActionsSummary(summary *github.RunSummary) {
+	if path := os.Getenv("GITHUB_STEP_SUMMARY"); path != "" {
+		if err := appendToFile(path, summary.Markdown()); err != nil {
+			slog.Warn("Failed to write the job summary", "error", err)
+		}
+	}
+
+	if path := os.Getenv("GITHUB_OUTPUT"); path != "" {
+		outputs := summary.Outputs()
+		names := make([]string, 0, len(outputs))
+		for name := range outputs {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		var sb strings.Builder
+		for _, name := range names {
+			fmt.Fprintf(&sb, "%s=%s\n", name, outputs[name])
+		}
+		if err := appendToFile(path, sb.String()); err != nil {
+			slog.Warn("Failed to write the step outputs", "error", err)
+		}
+	}
+}
+
+// printComments prints the comments rendered in a run, as a dry run or
+// render leaves them unposted. In Actions each one is folded into a group,
+// and workflow commands are turned off while it prints so that log text
+// quoted in it is not run as one.
+func printComments(summary *github.RunSummary, actions bool) {
+	for _, pr := range summary.PullRequests {
+		if pr.Comment == "" {
+			continue
+		}
+		title := fmt.Sprintf("%s on PR #%d", strings.ReplaceAll(pr.Action, "_", " "), pr.Number)
+		if !actions {
+			fmt.Printf("----- %s -----\n%s\n", title, pr.Comment)
+			continue
+		}
+
+		token := make([]byte, 16)
+		rand.Read(token)
+		stop := hex.EncodeToString(token)
+		fmt.Printf("::group::%s\n::stop-commands::%s\n%s\n::%s::\n::endgroup::\n", title, stop, pr.Comment, stop)
+	}
+}
+
+// appendToFile appends text to a file, as Actions expects for its command files
+func appendToFile(path, text string) error {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(text); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// loadConfig builds the client configuration from MONITOR_* environment variables
+func loadConfig() github.Config {
+	config := github.DefaultConfig()
+	if patterns := os.Getenv("MONITOR_ARTIFACT_PATTERNS"); patterns != "" {
+		config.ArtifactPatterns = splitList(patterns)
+	}
+	config.CompareWithLastSuccess = envBool("MONITOR_COMPARE_LAST_SUCCESS", config.CompareWithLastSuccess)
+	config.LogTailBytes = envInt("MONITOR_LOG_TAIL_BYTES", config.LogTailBytes)
+	config.SnippetLines = int(envInt("MONITOR_SNIPPET_LINES", int64(config.SnippetLines)))
+	config.ErrorKeywords = splitList(os.Getenv("MONITOR_ERROR_KEYWORDS"))
+	config.Parsers = splitList(os.Getenv("MONITOR_PARSERS"))
+	if _, err := github.DefaultRegistry().Select(config.Parsers); err != nil {
+		log.Fatalf("Invalid value for MONITOR_PARSERS: %v", err)
+	}
+	config.MaxConcurrentJobs = int(envInt("MONITOR_MAX_CONCURRENT_JOBS", int64(config.MaxConcurrentJobs)))
+	config.AnalysisTimeout = envDuration("MONITOR_ANALYSIS_TIMEOUT", config.AnalysisTimeout)
+	config.InlineComments = envBool("MONITOR_INLINE_COMMENTS", config.InlineComments)
+	config.CheckRun = envBool("MONITOR_CHECK_RUN", config.CheckRun)
+	config.ChecksToken = os.Getenv("MONITOR_CHECKS_TOKEN")
+	templateDir := os.Getenv("MONITOR_TEMPLATE_DIR")
+	if templateDir == "" {
+		templateDir = ".github/copilot-looper"
+	}
+	templates, err := github.LoadTemplates(templateDir)
+	if err != nil {
+		log.Fatalf("Invalid comment template in %s: %v", templateDir, err)
+	}
+	config.Templates = templates
+
+	instructionsFile := os.Getenv("MONITOR_INSTRUCTIONS")
+	if instructionsFile == "" {
+		instructionsFile = ".github/copilot-looper/instructions.yml"
+	}
+	instructions, err := github.LoadInstructions(instructionsFile)
+	if err != nil {
+		log.Fatalf("Invalid instructions in %s: %v", instructionsFile, err)
+	}
+	config.Instructions = instructions
+
+	config.RedactHighEntropy = envBool("MONITOR_REDACT_HIGH_ENTROPY", config.RedactHighEntropy)
+	// Patterns may contain commas, so they are given one per line
+	for _, pattern := range strings.Split(os.Getenv("MONITOR_REDACT_PATTERNS"), "\n") {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			log.Fatalf("Invalid pattern in MONITOR_REDACT_PATTERNS: %v", err)
+		}
+		config.RedactPatterns = append(config.RedactPatterns, re)
+	}
+	return config
+}
+
+// splitList splits a comma-separated list, dropping empty entries
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// envString returns an environment variable, or fallback when unset
+func envString(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+// envBool parses a boolean environment variable, returning fallback when unset
+func envBool(name string, fallback bool) bool {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid value for %s: %q", name, value)
+	}
+	return b
+}
+
+// envInt parses a non-negative integer environment variable, returning
+// fallback when unset
+func envInt(name string, fallback int64) int64 {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		log.Fatalf("Invalid value for %s: %q", name, value)
+	}
+	return n
+}
+
+// envDuration parses a duration environment variable such as "90s",
+// returning fallback when unset
+func envDuration(name string, fallback time.Duration) time.Duration {
+	value := os.Getenv(name)
+	if value == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		log.Fatalf("Invalid value for %s: %q", name, value)
 	}
-	fmt.Printf("\n=== Monitor completed successfully ===\n")
+	return d
 }
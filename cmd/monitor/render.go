@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"os"
+
+	"github.com/srt32/copilot-actions-looper/pkg/github"
+)
+
+// runRender prints the comments the monitor would post for a workflow_run
+// event, answering its API requests from saved responses instead of GitHub:
+//
+//	monitor render -event event.json [-jobs jobs.json] [-logs dir]
+//
+// Nothing is sent over the network. Settings come from the same MONITOR_*
+// environment variables as a run, so templates and instructions can be
+// tried before they are committed.
+func runRender(args []string) {
+	config := loadConfig()
+	fs := flag.NewFlagSet("render", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: monitor render -event event.json [-jobs jobs.json] [-logs dir]\n\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	eventFile := fs.String("event", "", "workflow_run event payload")
+	jobsFile := fs.String("jobs", "", "saved response of the list jobs API for the run, needed for a failed run")
+	logsDir := fs.String("logs", "", "directory with the log of each failed job, named <job id>.txt or <job id>.log")
+	templateDir := fs.String("templates", "", "directory with comment templates, overriding MONITOR_TEMPLATE_DIR")
+	repository := fs.String("repo", "", "owner/repo to link to (default the event's repository)")
+	prNumber := fs.Int("pr", 0, "pull request to comment on when the event has none, as for runs from forks")
+	logLevel := fs.String("log-level", "error", "lowest level logged to stderr: debug, info, warn or error")
+	fs.Parse(args)
+
+	if *eventFile == "" || fs.NArg() != 0 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(*logLevel)); err != nil {
+		log.Fatalf("Invalid log level %q", *logLevel)
+	}
+
+	eventData, err := os.ReadFile(*eventFile)
+	if err != nil {
+		log.Fatalf("Failed to read event file: %v", err)
+	}
+	var event github.WorkflowRunEvent
+	if err := json.Unmarshal(eventData, &event); err != nil {
+		log.Fatalf("Failed to parse workflow_run event: %v", err)
+	}
+	if event.WorkflowRun.ID == 0 {
+		log.Fatal("The event is not a workflow_run event")
+	}
+	if event.WorkflowRun.Conclusion == "failure" && *jobsFile == "" {
+		log.Fatal("Rendering the comment for a failed run needs its jobs, given with -jobs")
+	}
+	if *prNumber > 0 && len(event.WorkflowRun.PullRequests) == 0 {
+		event.WorkflowRun.PullRequests = []github.PullRequest{{Number: *prNumber}}
+	}
+	if *repository == "" {
+		*repository = event.Repository.FullName
+	}
+
+	fixtures := &github.Fixtures{LogsDir: *logsDir}
+	if *jobsFile != "" {
+		if fixtures.Jobs, err = os.ReadFile(*jobsFile); err != nil {
+			log.Fatalf("Failed to read jobs file: %v", err)
+		}
+	}
+	if *templateDir != "" {
+		if config.Templates, err = github.LoadTemplates(*templateDir); err != nil {
+			log.Fatalf("Invalid comment template in %s: %v", *templateDir, err)
+		}
+	}
+	config.HTTPClient = &http.Client{Transport: fixtures}
+	config.DryRun = true
+	config.Logger = slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+
+	client := github.NewClientWithConfig("", *repository, config)
+	registerProblemMatchers(client, splitList(os.Getenv("MONITOR_PROBLEM_MATCHERS")))
+	if err := client.HandleWorkflowRun(&event); err != nil {
+		log.Fatalf("Failed to render comments: %v", err)
+	}
+
+	summary := client.Summary()
+	printComments(summary, false)
+	for _, pr := range summary.PullRequests {
+		if pr.Comment == "" {
+			fmt.Fprintf(os.Stderr, "No comment for PR #%d: %s\n", pr.Number, pr.Decision)
+		}
+	}
+	if len(summary.PullRequests) == 0 {
+		fmt.Fprintf(os.Stderr, "No comment: %s\n", summary.Skipped)
+	}
+}
@@ -1,3 +1,5 @@
 module github.com/srt32/copilot-actions-looper
 
 go 1.24.7
+
+require gopkg.in/yaml.v3 v3.0.1
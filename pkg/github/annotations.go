@@ -0,0 +1,74 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// getJobAnnotations returns the failure-level annotations of the check run
+// behind a job, linked to their location at headSHA
+func (c *Client) getJobAnnotations(ctx context.Context, job Job, headSHA string) ([]Failure, error) {
+	if job.CheckRunURL == "" {
+		return nil, nil
+	}
+
+	var checkRun CheckRun
+	if err := c.getJSON(ctx, job.CheckRunURL, &checkRun); err != nil {
+		return nil, err
+	}
+	if checkRun.Output.AnnotationsCount == 0 {
+		return nil, nil
+	}
+
+	annotationsURL := checkRun.Output.AnnotationsURL
+	if annotationsURL == "" {
+		annotationsURL = fmt.Sprintf("%s/repos/%s/check-runs/%d/annotations", c.baseURL, c.repository, checkRun.ID)
+	}
+	var annotations []Annotation
+	if err := c.getJSON(ctx, annotationsURL+"?per_page=100", &annotations); err != nil {
+		return nil, err
+	}
+
+	var failures []Failure
+	for _, a := range annotations {
+		if a.AnnotationLevel != "failure" || isRunnerAnnotation(a) {
+			continue
+		}
+		failures = append(failures, Failure{
+			Parser:  "annotation",
+			TestID:  a.Title,
+			File:    a.Path,
+			Line:    a.StartLine,
+			Column:  a.StartColumn,
+			Message: a.Message,
+			Stack:   a.RawDetails,
+			URL:     c.blobURL(headSHA, a.Path, a.StartLine),
+		})
+	}
+	return failures, nil
+}
+
+// isRunnerAnnotation reports whether an annotation is the runner's generic
+// step failure notice rather than a problem in the code
+func isRunnerAnnotation(a Annotation) bool {
+	return a.Path == ".github" || strings.HasPrefix(a.Message, "Process completed with exit code")
+}
+
+// blobURL links to a line of a repository file at the given commit
+func (c *Client) blobURL(sha, path string, line int) string {
+	if sha == "" || path == "" || path == ".github" {
+		return ""
+	}
+	// Escaping keeps paths read from logs from breaking the markdown link
+	segments := strings.Split(strings.TrimPrefix(path, "./"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	link := fmt.Sprintf("%s/%s/blob/%s/%s", githubURL, c.repository, sha, strings.Join(segments, "/"))
+	if line > 0 {
+		link += fmt.Sprintf("#L%d", line)
+	}
+	return link
+}
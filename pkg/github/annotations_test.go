@@ -0,0 +1,75 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestGetJobAnnotations(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/check-runs/42":
+			fmt.Fprintf(w, `{"id":42,"output":{"annotations_count":3,"annotations_url":"%s/repos/owner/repo/check-runs/42/annotations"}}`, srv.URL)
+		case "/repos/owner/repo/check-runs/42/annotations":
+			fmt.Fprint(w, `[
+				{"path":"src/index.ts","start_line":3,"start_column":7,"annotation_level":"failure","title":"TS2322","message":"Type 'string' is not assignable to type 'number'."},
+				{"path":"src/util.ts","start_line":1,"annotation_level":"warning","message":"Unused import"},
+				{"path":".github","start_line":1,"annotation_level":"failure","message":"Process completed with exit code 2."}
+			]`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-token", "owner/repo")
+	client.baseURL = srv.URL
+	job := Job{ID: 42, Name: "build", CheckRunURL: srv.URL + "/repos/owner/repo/check-runs/42"}
+
+	failures, err := client.getJobAnnotations(context.Background(), job, "abc123")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []Failure{
+		{
+			Parser:  "annotation",
+			TestID:  "TS2322",
+			File:    "src/index.ts",
+			Line:    3,
+			Column:  7,
+			Message: "Type 'string' is not assignable to type 'number'.",
+			URL:     "https://github.com/owner/repo/blob/abc123/src/index.ts#L3",
+		},
+	}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Errorf("Expected:\n%+v\n\nGot:\n%+v", expected, failures)
+	}
+}
+
+func TestJobReportAddAnnotations(t *testing.T) {
+	report := JobReport{
+		Failures: []Failure{
+			{Parser: "tsc", File: "./src/index.ts", Line: 3, Message: "duplicate of the annotation"},
+			{Parser: "tsc", File: "src/other.ts", Line: 8, Message: "only in the log"},
+		},
+		Snippet: "error TS2322",
+	}
+
+	report.addAnnotations([]Failure{{Parser: "annotation", File: "src/index.ts", Line: 3}})
+
+	if len(report.Failures) != 2 {
+		t.Fatalf("Expected 2 failures, got %+v", report.Failures)
+	}
+	if report.Failures[0].Parser != "annotation" || report.Failures[1].File != "src/other.ts" {
+		t.Errorf("Expected the annotation first and the duplicate dropped, got %+v", report.Failures)
+	}
+	if report.Snippet != "" {
+		t.Errorf("Expected annotations to replace the heuristic snippet, got %q", report.Snippet)
+	}
+}
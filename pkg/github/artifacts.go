@@ -0,0 +1,316 @@
+package github
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// getArtifactTestResults downloads the run artifacts matching the configured
+// patterns and returns the failing tests reported inside them
+func (c *Client) getArtifactTestResults(ctx context.Context, runID int64) ([]Failure, error) {
+	artifacts, err := c.getRunArtifacts(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Failure
+	for _, artifact := range artifacts {
+		if !c.matchesArtifactPattern(artifact.Name) {
+			continue
+		}
+		if artifact.Expired {
+			c.logger.Debug("Skipping expired artifact", "artifact", artifact.Name, "artifact_id", artifact.ID)
+			continue
+		}
+		if c.config.MaxArtifactBytes > 0 && artifact.SizeInBytes > c.config.MaxArtifactBytes {
+			c.logger.Info("Skipping artifact over the size limit", "artifact", artifact.Name, "artifact_id", artifact.ID, "size", artifact.SizeInBytes)
+			continue
+		}
+
+		log := c.logger.With("artifact", artifact.Name, "artifact_id", artifact.ID)
+		data, err := c.downloadArtifact(ctx, artifact)
+		if err != nil {
+			log.Warn("Failed to download artifact", "error", err)
+			continue
+		}
+
+		failures, err := parseTestReportArchive(data, log)
+		if err != nil {
+			log.Warn("Failed to read artifact", "error", err)
+			continue
+		}
+		results = append(results, failures...)
+	}
+
+	return results, nil
+}
+
+// matchesArtifactPattern reports whether an artifact name matches any configured pattern
+func (c *Client) matchesArtifactPattern(name string) bool {
+	for _, pattern := range c.config.ArtifactPatterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// getRunArtifacts retrieves the artifacts uploaded by a workflow run, 100
+// per page
+func (c *Client) getRunArtifacts(ctx context.Context, runID int64) ([]Artifact, error) {
+	var artifacts []Artifact
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/repos/%s/actions/runs/%d/artifacts?per_page=100&page=%d", c.baseURL, c.repository, runID, page)
+		var artifactsResp ArtifactsResponse
+		if err := c.getJSON(ctx, url, &artifactsResp); err != nil {
+			return nil, err
+		}
+		artifacts = append(artifacts, artifactsResp.Artifacts...)
+		if len(artifactsResp.Artifacts) < 100 || len(artifacts) >= artifactsResp.TotalCount {
+			return artifacts, nil
+		}
+	}
+}
+
+// downloadArtifact downloads the zip archive of an artifact
+func (c *Client) downloadArtifact(ctx context.Context, artifact Artifact) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", artifact.ArchiveDownloadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	req.Header.Set("Accept", "application/vnd.github.v3+json")
+
+	start := time.Now()
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	c.logAPICall(req, resp.StatusCode, start)
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("GitHub API error: %d - %s", resp.StatusCode, string(body))
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+// parseTestReportArchive reads every JUnit XML and go test -json file in a
+// zip archive, logging the files that cannot be parsed to logger
+func parseTestReportArchive(data []byte, logger *slog.Logger) ([]Failure, error) {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	var failures []Failure
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+
+		var parse func(io.Reader) ([]Failure, error)
+		switch strings.ToLower(path.Ext(file.Name)) {
+		case ".xml":
+			parse = parseJUnitXML
+		case ".json", ".jsonl", ".ndjson":
+			parse = parseGoTestJSON
+		default:
+			continue
+		}
+
+		rc, err := file.Open()
+		if err != nil {
+			logger.Warn("Skipping unreadable test report", "file", file.Name, "error", err)
+			continue
+		}
+		fileFailures, err := parse(rc)
+		rc.Close()
+		if err != nil {
+			logger.Warn("Skipping unreadable test report", "file", file.Name, "error", err)
+			continue
+		}
+		failures = append(failures, fileFailures...)
+	}
+
+	return failures, nil
+}
+
+// junitSuite matches both <testsuites> and <testsuite> elements
+type junitSuite struct {
+	Suites []junitSuite `xml:"testsuite"`
+	Cases  []junitCase  `xml:"testcase"`
+}
+
+type junitCase struct {
+	ClassName string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	File      string        `xml:"file,attr"`
+	Line      int           `xml:"line,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure"`
+	Error     *junitProblem `xml:"error"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// parseJUnitXML returns the failed and errored test cases of a JUnit XML report
+func parseJUnitXML(r io.Reader) ([]Failure, error) {
+	var root junitSuite
+	if err := xml.NewDecoder(r).Decode(&root); err != nil {
+		return nil, err
+	}
+
+	var failures []Failure
+	var walk func(suite junitSuite)
+	walk = func(suite junitSuite) {
+		for _, tc := range suite.Cases {
+			problem := tc.Failure
+			if problem == nil {
+				problem = tc.Error
+			}
+			if problem == nil {
+				continue
+			}
+
+			f := Failure{
+				Parser:   "junit-xml",
+				TestID:   tc.Name,
+				File:     tc.File,
+				Line:     tc.Line,
+				Message:  strings.TrimSpace(problem.Message),
+				Stack:    strings.TrimSpace(problem.Text),
+				Duration: parseSeconds(tc.Time),
+			}
+			if tc.ClassName != "" {
+				f.TestID = tc.ClassName + "." + tc.Name
+			}
+			if f.Message == "" {
+				f.Message, f.Stack = f.Stack, ""
+			}
+			failures = append(failures, f)
+		}
+		for _, child := range suite.Suites {
+			walk(child)
+		}
+	}
+	walk(root)
+
+	return failures, nil
+}
+
+// parseSeconds converts a JUnit time attribute to a duration
+func parseSeconds(s string) time.Duration {
+	seconds, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		return 0
+	}
+	return time.Duration(seconds * float64(time.Second))
+}
+
+// goTestEvent is a single line of go test -json output
+type goTestEvent struct {
+	Action  string  `json:"Action"`
+	Package string  `json:"Package"`
+	Test    string  `json:"Test"`
+	Output  string  `json:"Output"`
+	Elapsed float64 `json:"Elapsed"`
+}
+
+// parseGoTestJSON returns the failed tests of a go test -json stream. Lines
+// that are not test events are ignored.
+func parseGoTestJSON(r io.Reader) ([]Failure, error) {
+	type key struct{ pkg, test string }
+	output := make(map[key][]string)
+	failedTests := make(map[string]bool)
+
+	var failures []Failure
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var event goTestEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil || event.Action == "" {
+			continue
+		}
+
+		k := key{event.Package, event.Test}
+		switch event.Action {
+		case "output":
+			line := strings.TrimRight(event.Output, "\n")
+			trimmed := strings.TrimSpace(line)
+			if trimmed == "" || strings.HasPrefix(trimmed, "=== ") || strings.HasPrefix(trimmed, "--- ") {
+				continue
+			}
+			if len(output[k]) < maxMessageLines {
+				output[k] = append(output[k], line)
+			}
+		case "fail":
+			f := Failure{
+				Parser:   "go test -json",
+				TestID:   event.Test,
+				Duration: time.Duration(event.Elapsed * float64(time.Second)),
+			}
+			if event.Test != "" {
+				failedTests[event.Package] = true
+			} else if failedTests[event.Package] {
+				// The package failed because of the tests already reported
+				continue
+			} else {
+				f.TestID = event.Package
+			}
+			for _, line := range output[k] {
+				if m := goTestLocationRegex.FindStringSubmatch(line); m != nil && f.File == "" {
+					f.File, f.Line, f.Message = m[1], atoi(m[2]), m[3]
+					continue
+				}
+				f.Message = appendLine(f.Message, strings.TrimSpace(line))
+			}
+			failures = append(failures, f)
+		}
+		if event.Action == "pass" || event.Action == "fail" || event.Action == "skip" {
+			delete(output, k)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Parent tests only failed because of their subtests
+	var result []Failure
+	for _, f := range failures {
+		if f.Message == "" && hasSubtestFailure(failures, f.TestID) {
+			continue
+		}
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+// hasSubtestFailure reports whether failures include a subtest of testID
+func hasSubtestFailure(failures []Failure, testID string) bool {
+	for _, f := range failures {
+		if strings.HasPrefix(f.TestID, testID+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package github
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const junitReport = `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites>
+  <testsuite name="com.example.AppTest" tests="2" failures="1">
+    <testcase classname="com.example.AppTest" name="testAdd" time="0.012">
+      <failure message="expected: &lt;4&gt; but was: &lt;3&gt;" type="AssertionFailedError">org.opentest4j.AssertionFailedError: expected: &lt;4&gt; but was: &lt;3&gt;
+	at com.example.AppTest.testAdd(AppTest.java:12)</failure>
+    </testcase>
+    <testcase classname="com.example.AppTest" name="testSub" time="0.001"/>
+  </testsuite>
+  <testsuite name="tests.test_math">
+    <testcase classname="tests.test_math" name="test_div" file="tests/test_math.py" line="9" time="1.5">
+      <error>ZeroDivisionError: division by zero</error>
+    </testcase>
+  </testsuite>
+</testsuites>`
+
+const goTestJSONReport = `{"Action":"run","Package":"example.com/app","Test":"TestAdd"}
+{"Action":"output","Package":"example.com/app","Test":"TestAdd","Output":"=== RUN   TestAdd\n"}
+{"Action":"run","Package":"example.com/app","Test":"TestAdd/positive"}
+{"Action":"output","Package":"example.com/app","Test":"TestAdd/positive","Output":"    math_test.go:47: Add(2, 3) = 6; expected 5\n"}
+{"Action":"output","Package":"example.com/app","Test":"TestAdd/positive","Output":"--- FAIL: TestAdd/positive (0.00s)\n"}
+{"Action":"fail","Package":"example.com/app","Test":"TestAdd/positive","Elapsed":0.25}
+{"Action":"fail","Package":"example.com/app","Test":"TestAdd","Elapsed":0.25}
+{"Action":"pass","Package":"example.com/app","Test":"TestSub","Elapsed":0}
+{"Action":"fail","Package":"example.com/app","Elapsed":0.3}
+not json
+{"Action":"output","Package":"example.com/broken","Output":"# example.com/broken\n"}
+{"Action":"output","Package":"example.com/broken","Output":"./broken.go:3:1: syntax error\n"}
+{"Action":"fail","Package":"example.com/broken","Elapsed":0}
+`
+
+func TestParseJUnitXML(t *testing.T) {
+	failures, err := parseJUnitXML(strings.NewReader(junitReport))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []Failure{
+		{
+			Parser:   "junit-xml",
+			TestID:   "com.example.AppTest.testAdd",
+			Message:  "expected: <4> but was: <3>",
+			Stack:    "org.opentest4j.AssertionFailedError: expected: <4> but was: <3>\n\tat com.example.AppTest.testAdd(AppTest.java:12)",
+			Duration: 12 * time.Millisecond,
+		},
+		{
+			Parser:   "junit-xml",
+			TestID:   "tests.test_math.test_div",
+			File:     "tests/test_math.py",
+			Line:     9,
+			Message:  "ZeroDivisionError: division by zero",
+			Duration: 1500 * time.Millisecond,
+		},
+	}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Errorf("Expected:\n%+v\n\nGot:\n%+v", expected, failures)
+	}
+}
+
+func TestParseGoTestJSON(t *testing.T) {
+	failures, err := parseGoTestJSON(strings.NewReader(goTestJSONReport))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	expected := []Failure{
+		{
+			Parser:   "go test -json",
+			TestID:   "TestAdd/positive",
+			File:     "math_test.go",
+			Line:     47,
+			Message:  "Add(2, 3) = 6; expected 5",
+			Duration: 250 * time.Millisecond,
+		},
+		{
+			Parser:  "go test -json",
+			TestID:  "example.com/broken",
+			Message: "# example.com/broken\n./broken.go:3:1: syntax error",
+		},
+	}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Errorf("Expected:\n%+v\n\nGot:\n%+v", expected, failures)
+	}
+}
+
+// buildZip creates an in-memory zip archive with the given files
+func buildZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := f.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestGetRunArtifacts_Pages(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		resp := ArtifactsResponse{TotalCount: 150}
+		count := 100
+		if page == "2" {
+			count = 50
+		}
+		for i := 0; i < count; i++ {
+			resp.Artifacts = append(resp.Artifacts, Artifact{ID: int64(len(pages)*1000 + i)})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-token", "owner/repo")
+	client.baseURL = srv.URL
+
+	artifacts, err := client.getRunArtifacts(context.Background(), 123)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(artifacts) != 150 || !reflect.DeepEqual(pages, []string{"1", "2"}) {
+		t.Errorf("Expected 150 artifacts from pages 1 and 2, got %d from %v", len(artifacts), pages)
+	}
+}
+
+func TestParseTestReportArchive_UnreadableFile(t *testing.T) {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	// An entry compressed with a method the reader does not know cannot be opened
+	w.RegisterCompressor(99, func(out io.Writer) (io.WriteCloser, error) { return nopWriteCloser{out}, nil })
+	bad, err := w.CreateHeader(&zip.FileHeader{Name: "a/TEST-Broken.xml", Method: 99})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad.Write([]byte(junitReport))
+	good, err := w.Create("b/TEST-AppTest.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	good.Write([]byte(junitReport))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	failures, err := parseTestReportArchive(buf.Bytes(), slog.Default())
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(failures) == 0 {
+		t.Error("Expected the failures of the readable file to be kept")
+	}
+}
+
+// nopWriteCloser adds a no-op Close to a writer
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestGetArtifactTestResults(t *testing.T) {
+	archive := buildZip(t, map[string]string{
+		"reports/TEST-AppTest.xml": junitReport,
+		"reports/go-test.json":     goTestJSONReport,
+		"reports/README.txt":       "ignored",
+	})
+
+	var downloaded []string
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/actions/runs/123/artifacts":
+			fmt.Fprintf(w, `{"total_count":3,"artifacts":[
+				{"id":1,"name":"test-results","size_in_bytes":%d,"archive_download_url":"%s/download/1"},
+				{"id":2,"name":"coverage","size_in_bytes":10,"archive_download_url":"%s/download/2"},
+				{"id":3,"name":"test-results-old","size_in_bytes":10,"archive_download_url":"%s/download/3","expired":true}
+			]}`, len(archive), srv.URL, srv.URL, srv.URL)
+		case "/download/1":
+			downloaded = append(downloaded, r.URL.Path)
+			w.Write(archive)
+		default:
+			downloaded = append(downloaded, r.URL.Path)
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig()
+	config.ArtifactPatterns = []string{"test-results*"}
+	client := NewClientWithConfig("test-token", "owner/repo", config)
+	client.baseURL = srv.URL
+
+	results, err := client.getArtifactTestResults(context.Background(), 123)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(results) != 4 {
+		t.Errorf("Expected 4 failing tests from the archive, got %d: %+v", len(results), results)
+	}
+	if !reflect.DeepEqual(downloaded, []string{"/download/1"}) {
+		t.Errorf("Expected only the matching, unexpired artifact to be downloaded, got %v", downloaded)
+	}
+}
+
+func TestFailureReportSetTestResults(t *testing.T) {
+	report := &FailureReport{
+		Jobs: []JobReport{
+			{
+				Job: Job{Name: "test"},
+				Failures: []Failure{
+					{Parser: "go test", TestID: "TestAdd/positive"},
+					{Parser: "go test", File: "./math.go", Line: 3, Message: "vet: unreachable code"},
+				},
+			},
+			{Job: Job{Name: "lint"}, Snippet: "error: something"},
+			{Job: Job{Name: "integration"}, Snippet: "--- FAIL: TestSubtract (0.01s)\nerror: got 1"},
+		},
+	}
+
+	report.setTestResults([]Failure{
+		{Parser: "go test -json", TestID: "TestAdd/positive"},
+		{Parser: "junit", TestID: "math.TestSubtract"},
+	})
+
+	if len(report.Jobs[0].Failures) != 1 || report.Jobs[0].Failures[0].TestID != "" {
+		t.Errorf("Expected the log-parsed duplicate to be dropped, got %+v", report.Jobs[0].Failures)
+	}
+	if report.Jobs[1].Snippet != "error: something" {
+		t.Errorf("Expected the snippet of a job the artifacts don't cover to be kept, got %q", report.Jobs[1].Snippet)
+	}
+	if report.Jobs[2].Snippet != "" {
+		t.Errorf("Expected the snippet of a job that ran a reported test to be dropped, got %q", report.Jobs[2].Snippet)
+	}
+}
@@ -0,0 +1,189 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// checkRunName is the name of the check run the monitor publishes
+const checkRunName = "Copilot Loop"
+
+// maxCheckRunAnnotations is how many annotations the API accepts in one
+// request; more are added by updating the check run
+const maxCheckRunAnnotations = 50
+
+// maxCheckRunSummaryLength is the largest check run summary GitHub accepts
+const maxCheckRunSummaryLength = 65535
+
+// publishCheckRun records the state of the loop on the head commit of a
+// workflow run as a "Copilot Loop" check run. Its conclusion covers every
+// workflow on the commit, not only the one handled: failure when any failed,
+// success when all passed, and neutral otherwise. report holds the failures
+// of a failed workflow, already redacted, and is nil for a successful one.
+//
+// Check runs are written with Config.ChecksToken, as a personal access
+// token that posts the comments cannot create them.
+//
+// A new check run is created on each update rather than the last one edited,
+// since annotations can only ever be added to a check run. GitHub shows the
+// latest check run of a name.
+func (c *Client) publishCheckRun(ctx context.Context, prNumber int, workflow *WorkflowRun, report *FailureReport) error {
+	runs, err := c.getCommitRuns(ctx, workflow)
+	if err != nil {
+		return fmt.Errorf("failed to get workflow runs: %w", err)
+	}
+	conclusion, title := loopConclusion(runs)
+	c.logger.Info("Concluded the check run", "pr", prNumber, "run_id", workflow.ID, "conclusion", conclusion, "title", title)
+
+	var summary string
+	var annotations []Annotation
+	if report != nil {
+		render := func(r *FailureReport) string {
+			data := newCommentData(r)
+			data.Runs = runs
+			return c.renderComment(CheckRunSummary, data)
+		}
+		summary = fitComment(report.sanitized(), render, maxCheckRunSummaryLength)
+		annotations = c.checkRunAnnotations(report)
+	} else {
+		summary = c.renderComment(CheckRunSummary, &CommentData{
+			Workflow:    workflow,
+			PullRequest: prNumber,
+			Attempt:     max(workflow.RunAttempt, 1),
+			Runs:        runs,
+		})
+	}
+
+	batch := annotations[:min(len(annotations), maxCheckRunAnnotations)]
+	token := c.config.ChecksToken
+	if token == "" {
+		token = c.token
+	}
+	var created CheckRun
+	err = c.sendJSONAs(token, "POST", fmt.Sprintf("%s/repos/%s/check-runs", c.baseURL, c.repository), NewCheckRun{
+		Name:       checkRunName,
+		HeadSHA:    workflow.HeadSHA,
+		Status:     "completed",
+		Conclusion: conclusion,
+		Output:     NewCheckRunOutput{Title: title, Summary: summary, Annotations: batch},
+	}, &created)
+	if err != nil {
+		return err
+	}
+
+	for annotations = annotations[len(batch):]; len(annotations) > 0; annotations = annotations[len(batch):] {
+		batch = annotations[:min(len(annotations), maxCheckRunAnnotations)]
+		err := c.sendJSONAs(token, "PATCH", fmt.Sprintf("%s/repos/%s/check-runs/%d", c.baseURL, c.repository, created.ID), NewCheckRun{
+			Output: NewCheckRunOutput{Title: title, Summary: summary, Annotations: batch},
+		}, nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getCommitRuns returns the latest run of each workflow on the head commit
+// of a workflow run, with the handled run as given in its event. Runs of
+// workflows triggered by workflow_run, such as the monitor itself, are left
+// out.
+func (c *Client) getCommitRuns(ctx context.Context, workflow *WorkflowRun) ([]WorkflowRun, error) {
+	runsURL := fmt.Sprintf("%s/repos/%s/actions/runs?head_sha=%s&per_page=100",
+		c.baseURL, c.repository, url.QueryEscape(workflow.HeadSHA))
+	var runsResp WorkflowRunsResponse
+	if err := c.getJSON(ctx, runsURL, &runsResp); err != nil {
+		return nil, err
+	}
+
+	var runs []WorkflowRun
+	latest := make(map[int64]int)
+	for _, run := range append(runsResp.WorkflowRuns, *workflow) {
+		if run.Event == "workflow_run" {
+			continue
+		}
+		if i, ok := latest[run.WorkflowID]; ok {
+			if run.ID >= runs[i].ID {
+				runs[i] = run
+			}
+			continue
+		}
+		latest[run.WorkflowID] = len(runs)
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// loopConclusion sums up the runs of a commit as a check run conclusion and
+// title. Runs still in progress do not count; their own completion updates
+// the check run again.
+func loopConclusion(runs []WorkflowRun) (conclusion, title string) {
+	var completed, failed, passed int
+	for _, run := range runs {
+		if run.Status != "completed" {
+			continue
+		}
+		completed++
+		switch run.Conclusion {
+		case "failure", "timed_out", "startup_failure":
+			failed++
+		case "success", "neutral", "skipped":
+			passed++
+		}
+	}
+
+	switch {
+	case completed == 0:
+		return "neutral", "No completed workflows yet"
+	case failed > 0:
+		return "failure", fmt.Sprintf("%d of %d workflow(s) failed", failed, completed)
+	case passed == completed:
+		return "success", fmt.Sprintf("All %d workflow(s) passed", completed)
+	default:
+		return "neutral", fmt.Sprintf("%d of %d workflow(s) did not pass", completed-passed, completed)
+	}
+}
+
+// checkRunAnnotations turns the failures of a report that point at a line of
+// a repository file into annotations. Failures read from other check runs'
+// annotations are left out, as they already show on the diff.
+func (c *Client) checkRunAnnotations(report *FailureReport) []Annotation {
+	var failures []Failure
+	failures = append(failures, report.TestResults...)
+	for _, job := range report.Jobs {
+		failures = append(failures, job.Failures...)
+	}
+
+	var annotations []Annotation
+	seen := make(map[string]bool)
+	for _, f := range failures {
+		file, ok := c.repositoryPath(f)
+		if !ok || f.Line <= 0 || f.Parser == "annotation" {
+			continue
+		}
+		key := fmt.Sprintf("%s:%d:%s", file, f.Line, f.Message)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		title := f.TestID
+		if title == "" {
+			title = f.Parser
+		}
+		message := f.Message
+		if message == "" {
+			message = title
+		}
+		annotations = append(annotations, Annotation{
+			Path:            file,
+			StartLine:       f.Line,
+			EndLine:         f.Line,
+			AnnotationLevel: "failure",
+			Title:           title,
+			Message:         message,
+			RawDetails:      f.Stack,
+		})
+	}
+	return annotations
+}
@@ -0,0 +1,232 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoopConclusion(t *testing.T) {
+	run := func(conclusion string) WorkflowRun {
+		return WorkflowRun{Status: "completed", Conclusion: conclusion}
+	}
+
+	tests := []struct {
+		name               string
+		runs               []WorkflowRun
+		expectedConclusion string
+		expectedTitle      string
+	}{
+		{
+			name:               "all passed",
+			runs:               []WorkflowRun{run("success"), run("skipped")},
+			expectedConclusion: "success",
+			expectedTitle:      "All 2 workflow(s) passed",
+		},
+		{
+			name:               "one failed",
+			runs:               []WorkflowRun{run("success"), run("failure"), run("cancelled")},
+			expectedConclusion: "failure",
+			expectedTitle:      "1 of 3 workflow(s) failed",
+		},
+		{
+			name:               "timed out counts as failed",
+			runs:               []WorkflowRun{run("timed_out")},
+			expectedConclusion: "failure",
+			expectedTitle:      "1 of 1 workflow(s) failed",
+		},
+		{
+			name:               "cancelled without failures",
+			runs:               []WorkflowRun{run("success"), run("cancelled")},
+			expectedConclusion: "neutral",
+			expectedTitle:      "1 of 2 workflow(s) did not pass",
+		},
+		{
+			name:               "runs in progress do not count",
+			runs:               []WorkflowRun{run("success"), {Status: "in_progress"}},
+			expectedConclusion: "success",
+			expectedTitle:      "All 1 workflow(s) passed",
+		},
+		{
+			name:               "no completed runs",
+			runs:               []WorkflowRun{{Status: "in_progress"}},
+			expectedConclusion: "neutral",
+			expectedTitle:      "No completed workflows yet",
+		},
+		{
+			name:               "no runs",
+			expectedConclusion: "neutral",
+			expectedTitle:      "No completed workflows yet",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conclusion, title := loopConclusion(tt.runs)
+			if conclusion != tt.expectedConclusion || title != tt.expectedTitle {
+				t.Errorf("Expected %s %q, got %s %q", tt.expectedConclusion, tt.expectedTitle, conclusion, title)
+			}
+		})
+	}
+}
+
+func TestCheckRunAnnotations(t *testing.T) {
+	client := NewClient("test-token", "owner/repo")
+	failure := Failure{Parser: "tsc", File: "src/app.ts", Line: 3, Message: "TS2322"}
+	report := &FailureReport{
+		TestResults: []Failure{{Parser: "junit", TestID: "AppTest.adds", File: "/home/runner/work/repo/repo/src/test/AppTest.java", Line: 9, Message: "expected 5", Stack: "at AppTest.adds"}},
+		Jobs: []JobReport{
+			{Failures: []Failure{failure, {Parser: "annotation", File: "src/app.ts", Line: 3, Message: "TS2322"}}},
+			{Failures: []Failure{failure, {Parser: "go test", TestID: "TestAdd", File: "math_test.go", Line: 12, Message: "got 6"}}},
+		},
+	}
+
+	annotations := client.checkRunAnnotations(report)
+	if len(annotations) != 2 {
+		t.Fatalf("Expected 2 annotations, got %+v", annotations)
+	}
+	expected := Annotation{Path: "src/test/AppTest.java", StartLine: 9, EndLine: 9, AnnotationLevel: "failure", Title: "AppTest.adds", Message: "expected 5", RawDetails: "at AppTest.adds"}
+	if annotations[0] != expected {
+		t.Errorf("Expected %+v, got %+v", expected, annotations[0])
+	}
+	if annotations[1].Path != "src/app.ts" || annotations[1].Title != "tsc" {
+		t.Errorf("Expected the tsc failure titled by its parser, got %+v", annotations[1])
+	}
+}
+
+func TestPublishCheckRun(t *testing.T) {
+	var created NewCheckRun
+	var updates []NewCheckRun
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/actions/runs":
+			if r.URL.Query().Get("head_sha") != "abc123" {
+				t.Errorf("Expected runs of abc123, got %q", r.URL.RawQuery)
+			}
+			json.NewEncoder(w).Encode(WorkflowRunsResponse{WorkflowRuns: []WorkflowRun{
+				{ID: 10, WorkflowID: 1, Name: "CI", Status: "in_progress", Event: "pull_request"},
+				{ID: 8, WorkflowID: 2, Name: "Lint", Status: "completed", Conclusion: "failure", Event: "pull_request"},
+				{ID: 9, WorkflowID: 2, Name: "Lint", Status: "completed", Conclusion: "success", Event: "pull_request"},
+				{ID: 11, WorkflowID: 3, Name: "Monitor", Status: "in_progress", Event: "workflow_run"},
+			}})
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/repo/check-runs":
+			json.NewDecoder(r.Body).Decode(&created)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"id": 42}`)
+		case r.Method == "PATCH" && r.URL.Path == "/repos/owner/repo/check-runs/42":
+			var update NewCheckRun
+			json.NewDecoder(r.Body).Decode(&update)
+			updates = append(updates, update)
+			fmt.Fprint(w, `{"id": 42}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-token", "owner/repo")
+	client.baseURL = srv.URL
+
+	workflow := &WorkflowRun{ID: 10, WorkflowID: 1, Name: "CI", HeadSHA: "abc123", Status: "completed", Conclusion: "failure", Event: "pull_request"}
+	var failures []Failure
+	for i := 1; i <= 60; i++ {
+		failures = append(failures, Failure{Parser: "tsc", File: "src/app.ts", Line: i, Message: fmt.Sprintf("error %d", i)})
+	}
+	report := &FailureReport{Workflow: workflow, PRNumber: 7, Jobs: []JobReport{{Job: Job{Name: "build"}, Failures: failures}}}
+
+	if err := client.publishCheckRun(context.Background(), 7, workflow, report); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if created.Name != checkRunName || created.HeadSHA != "abc123" || created.Status != "completed" || created.Conclusion != "failure" {
+		t.Errorf("Expected a completed failed %s check run on abc123, got %+v", checkRunName, created)
+	}
+	if created.Output.Title != "1 of 2 workflow(s) failed" {
+		t.Errorf("Expected the title to count the latest run of each workflow, got %q", created.Output.Title)
+	}
+	for _, expected := range []string{"[CI]", "❌ failure", "[Lint]", "✅ success", "- build", "error 60"} {
+		if !strings.Contains(created.Output.Summary, expected) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", expected, created.Output.Summary)
+		}
+	}
+	if strings.Contains(created.Output.Summary, "Monitor") {
+		t.Errorf("Expected runs triggered by workflow_run to be left out, got:\n%s", created.Output.Summary)
+	}
+	if len(created.Output.Annotations) != maxCheckRunAnnotations {
+		t.Errorf("Expected %d annotations on creation, got %d", maxCheckRunAnnotations, len(created.Output.Annotations))
+	}
+	if len(updates) != 1 || len(updates[0].Output.Annotations) != 10 || updates[0].Output.Annotations[0].StartLine != 51 {
+		t.Errorf("Expected the remaining 10 annotations in one update, got %+v", updates)
+	}
+}
+
+func TestHandleWorkflowRun_CheckRunForbidden(t *testing.T) {
+	var commented bool
+	var checkRunTokens []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/pulls/7":
+			json.NewEncoder(w).Encode(PullRequest{Number: 7, User: User{Login: "copilot"}})
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/actions/runs":
+			json.NewEncoder(w).Encode(WorkflowRunsResponse{})
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/repo/issues/7/comments":
+			if got := r.Header.Get("Authorization"); got != "Bearer pat-token" {
+				t.Errorf("Expected the comment to be posted with the client's token, got %q", got)
+			}
+			commented = true
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{"html_url": "https://github.com/owner/repo/pull/7#issuecomment-1"}`)
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/repo/check-runs":
+			checkRunTokens = append(checkRunTokens, r.Header.Get("Authorization"))
+			w.WriteHeader(http.StatusForbidden)
+			fmt.Fprint(w, `{"message": "You must authenticate via a GitHub App."}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	tests := []struct {
+		name          string
+		checksToken   string
+		expectedToken string
+	}{
+		{name: "checks token", checksToken: "actions-token", expectedToken: "Bearer actions-token"},
+		{name: "no checks token", expectedToken: "Bearer pat-token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commented, checkRunTokens = false, nil
+			var logs strings.Builder
+			config := DefaultConfig()
+			config.ChecksToken = tt.checksToken
+			config.Logger = slog.New(slog.NewTextHandler(&logs, nil))
+			client := NewClientWithConfig("pat-token", "owner/repo", config)
+			client.baseURL = srv.URL
+
+			event := &WorkflowRunEvent{WorkflowRun: WorkflowRun{
+				ID: 1, Name: "CI", HeadSHA: "abc123", Status: "completed", Conclusion: "success",
+				PullRequests: []PullRequest{{Number: 7}},
+			}}
+			if err := client.HandleWorkflowRun(event); err != nil {
+				t.Fatalf("Expected a rejected check run not to fail the run, got %v", err)
+			}
+
+			if !commented {
+				t.Error("Expected the success comment to be posted")
+			}
+			if len(checkRunTokens) != 1 || checkRunTokens[0] != tt.expectedToken {
+				t.Errorf("Expected one check run request with %q, got %v", tt.expectedToken, checkRunTokens)
+			}
+			if !strings.Contains(logs.String(), "Failed to publish the check run") || !strings.Contains(logs.String(), "403") {
+				t.Errorf("Expected the 403 to be logged, got:\n%s", logs.String())
+			}
+		})
+	}
+}
@@ -2,15 +2,20 @@ package github
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 )
 
 const (
 	githubAPIURL = "https://api.github.com"
+	githubURL    = "https://github.com"
 )
 
 var copilotBotPatterns = []string{
@@ -23,74 +28,126 @@ var copilotBotPatterns = []string{
 type Client struct {
 	token      string
 	repository string
+	baseURL    string
 	httpClient *http.Client
+	extractors *Registry
+	redactor   *Redactor
+	templates  *Templates
+	config     Config
+	summary    *RunSummary
+	logger     *slog.Logger
 }
 
-// NewClient creates a new GitHub API client
+// NewClient creates a new GitHub API client with the default configuration
 func NewClient(token, repository string) *Client {
+	return NewClientWithConfig(token, repository, DefaultConfig())
+}
+
+// NewClientWithConfig creates a new GitHub API client with the given configuration
+func NewClientWithConfig(token, repository string, config Config) *Client {
+	templates := config.Templates
+	if templates == nil {
+		templates = DefaultTemplates()
+	}
+	logger := config.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	extractors := DefaultRegistry()
+	if len(config.Parsers) > 0 {
+		selected, err := extractors.Select(config.Parsers)
+		if err != nil {
+			logger.Warn("Ignoring the parser selection, running all parsers", "error", err)
+		} else {
+			extractors = selected
+		}
+	}
 	return &Client{
 		token:      token,
 		repository: repository,
-		httpClient: &http.Client{},
+		baseURL:    githubAPIURL,
+		httpClient: httpClient,
+		extractors: extractors,
+		redactor:   NewRedactor(config.RedactPatterns, config.RedactHighEntropy),
+		templates:  templates,
+		config:     config,
+		summary:    &RunSummary{DryRun: config.DryRun},
+		logger:     logger.With("repo", repository),
 	}
 }
 
+// Summary returns the record of what the client did, for reporting at the
+// end of a run
+func (c *Client) Summary() *RunSummary {
+	return c.summary
+}
+
+// RegisterExtractor adds an extractor to the ones run over failed job logs
+func (c *Client) RegisterExtractor(e Extractor) {
+	c.extractors.Register(e)
+}
+
 // HandleWorkflowRun processes a workflow_run event
 func (c *Client) HandleWorkflowRun(event *WorkflowRunEvent) error {
-	fmt.Printf("\n--- Processing Workflow Run Event ---\n")
-	fmt.Printf("Workflow Name: %s\n", event.WorkflowRun.Name)
-	fmt.Printf("Workflow Run ID: %d\n", event.WorkflowRun.ID)
-	fmt.Printf("Status: %s\n", event.WorkflowRun.Status)
-	fmt.Printf("Conclusion: %s\n", event.WorkflowRun.Conclusion)
-	fmt.Printf("Branch: %s\n", event.WorkflowRun.HeadBranch)
-	fmt.Printf("Workflow URL: %s\n", event.WorkflowRun.HTMLURL)
+	log := c.logger.With("run_id", event.WorkflowRun.ID)
+	log.Info("Processing workflow run event",
+		"workflow", event.WorkflowRun.Name,
+		"status", event.WorkflowRun.Status,
+		"conclusion", event.WorkflowRun.Conclusion,
+		"branch", event.WorkflowRun.HeadBranch,
+		"url", event.WorkflowRun.HTMLURL)
+	c.summary.Workflow = &event.WorkflowRun
 
 	// Only process completed workflow runs
 	if event.WorkflowRun.Status != "completed" {
-		fmt.Printf("⏸️  Workflow run %d is not completed (status: %s), skipping\n",
-			event.WorkflowRun.ID, event.WorkflowRun.Status)
+		log.Info("Workflow run is not completed, skipping", "status", event.WorkflowRun.Status)
+		c.summary.Skipped = "The workflow run is not completed"
 		return nil
 	}
 
 	// Check if there are associated pull requests
-	fmt.Printf("Associated PRs: %d\n", len(event.WorkflowRun.PullRequests))
 	if len(event.WorkflowRun.PullRequests) == 0 {
-		fmt.Printf("❌ Workflow run %d has no associated pull requests, skipping\n",
-			event.WorkflowRun.ID)
+		log.Info("Workflow run has no associated pull requests, skipping")
+		c.summary.Skipped = "The workflow run has no associated pull requests"
 		return nil
 	}
 
 	// Check each PR to see if it's from Copilot
 	for _, pr := range event.WorkflowRun.PullRequests {
-		fmt.Printf("\nChecking PR #%d...\n", pr.Number)
-		fmt.Printf("Fetching PR details from GitHub API...\n")
+		prLog := log.With("pr", pr.Number)
+		record := c.summary.pullRequest(pr.Number)
 		isCopilotPR, err := c.isCopilotPR(pr.Number)
 		if err != nil {
-			fmt.Printf("❌ Error checking if PR #%d is from Copilot: %v\n", pr.Number, err)
+			prLog.Warn("Failed to check if the PR is from Copilot", "error", err)
+			record.Decision = fmt.Sprintf("Could not check the author: %v", err)
 			continue
 		}
 
 		if !isCopilotPR {
-			fmt.Printf("❌ PR #%d is not from Copilot, skipping\n", pr.Number)
+			prLog.Info("PR is not from Copilot, skipping")
+			record.Decision = "Not a Copilot PR"
 			continue
 		}
 
-		fmt.Printf("✅ Confirmed Copilot PR #%d\n", pr.Number)
-		fmt.Printf("Processing workflow conclusion: %s\n", event.WorkflowRun.Conclusion)
+		prLog.Info("Confirmed Copilot PR")
+		record.Copilot = true
 
 		// Handle based on workflow conclusion
 		if event.WorkflowRun.Conclusion == "failure" {
-			fmt.Printf("🔴 Handling failed workflow...\n")
 			if err := c.handleFailedWorkflow(pr.Number, &event.WorkflowRun); err != nil {
 				return fmt.Errorf("failed to handle failed workflow: %w", err)
 			}
 		} else if event.WorkflowRun.Conclusion == "success" {
-			fmt.Printf("🟢 Handling successful workflow...\n")
 			if err := c.handleSuccessfulWorkflow(pr.Number, &event.WorkflowRun); err != nil {
 				return fmt.Errorf("failed to handle successful workflow: %w", err)
 			}
 		} else {
-			fmt.Printf("ℹ️  Workflow conclusion '%s' - no action needed\n", event.WorkflowRun.Conclusion)
+			prLog.Info("No action needed for the workflow conclusion", "conclusion", event.WorkflowRun.Conclusion)
+			record.Decision = fmt.Sprintf("No action for conclusion '%s'", event.WorkflowRun.Conclusion)
 		}
 	}
 
@@ -99,31 +156,32 @@ func (c *Client) HandleWorkflowRun(event *WorkflowRunEvent) error {
 
 // HandlePullRequest processes a pull_request event
 func (c *Client) HandlePullRequest(event *PullRequestEvent) error {
-	fmt.Printf("\n--- Processing Pull Request Event ---\n")
-	fmt.Printf("PR Number: #%d\n", event.PullRequest.Number)
-	fmt.Printf("PR Title: %s\n", event.PullRequest.Title)
-	fmt.Printf("PR Author: %s (type: %s)\n", event.PullRequest.User.Login, event.PullRequest.User.Type)
-	fmt.Printf("PR URL: %s\n", event.PullRequest.HTMLURL)
-	fmt.Printf("Base Branch: %s\n", event.PullRequest.Base.Ref)
-	fmt.Printf("Head Branch: %s\n", event.PullRequest.Head.Ref)
+	log := c.logger.With("pr", event.PullRequest.Number)
+	log.Info("Processing pull request event",
+		"title", event.PullRequest.Title,
+		"author", event.PullRequest.User.Login,
+		"author_type", event.PullRequest.User.Type,
+		"url", event.PullRequest.HTMLURL,
+		"base", event.PullRequest.Base.Ref,
+		"head", event.PullRequest.Head.Ref)
 
 	// Check if the PR is from Copilot
-	fmt.Printf("Checking if PR is from Copilot...\n")
+	record := c.summary.pullRequest(event.PullRequest.Number)
 	if !isCopilotUser(event.PullRequest.User.Login) {
-		fmt.Printf("❌ PR #%d is NOT from Copilot (user: %s, type: %s), skipping\n",
-			event.PullRequest.Number, event.PullRequest.User.Login, event.PullRequest.User.Type)
+		log.Info("PR is not from Copilot, skipping")
+		record.Decision = "Not a Copilot PR"
 		return nil
 	}
+	record.Copilot = true
+	record.Decision = "Copilot PR, its workflow runs will be monitored"
 
-	fmt.Printf("✅ Detected Copilot PR #%d: %s\n", event.PullRequest.Number, event.PullRequest.Title)
-	fmt.Printf("This PR will be monitored for workflow runs\n")
+	log.Info("Detected Copilot PR, its workflow runs will be monitored")
 	return nil
 }
 
 // isCopilotPR checks if a PR was created by Copilot
 func (c *Client) isCopilotPR(prNumber int) (bool, error) {
-	url := fmt.Sprintf("%s/repos/%s/pulls/%d", githubAPIURL, c.repository, prNumber)
-	fmt.Printf("  → API call: GET %s\n", url)
+	url := fmt.Sprintf("%s/repos/%s/pulls/%d", c.baseURL, c.repository, prNumber)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -133,13 +191,14 @@ func (c *Client) isCopilotPR(prNumber int) (bool, error) {
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return false, err
 	}
 	defer resp.Body.Close()
 
-	fmt.Printf("  → API response: %d\n", resp.StatusCode)
+	c.logAPICall(req, resp.StatusCode, start)
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
 		return false, fmt.Errorf("GitHub API error: %d - %s", resp.StatusCode, string(body))
@@ -151,7 +210,7 @@ func (c *Client) isCopilotPR(prNumber int) (bool, error) {
 	}
 
 	isCopilot := isCopilotUser(pr.User.Login)
-	fmt.Printf("  → User '%s' is Copilot: %v\n", pr.User.Login, isCopilot)
+	c.logger.Debug("Checked the PR author", "pr", prNumber, "author", pr.User.Login, "copilot", isCopilot)
 	return isCopilot, nil
 }
 
@@ -173,94 +232,319 @@ func isCopilotUser(login string) bool {
 
 // handleFailedWorkflow handles a failed workflow run
 func (c *Client) handleFailedWorkflow(prNumber int, workflow *WorkflowRun) error {
-	fmt.Printf("\n--- Handling Failed Workflow ---\n")
-	fmt.Printf("Workflow: '%s'\n", workflow.Name)
-	fmt.Printf("PR: #%d\n", prNumber)
+	log := c.logger.With("pr", prNumber, "run_id", workflow.ID)
+	log.Info("Handling failed workflow", "workflow", workflow.Name)
+
+	// Everything up to posting the comment shares one deadline, so a slow
+	// log download leaves a partial report rather than no report
+	ctx := context.Background()
+	if c.config.AnalysisTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.config.AnalysisTimeout)
+		defer cancel()
+	}
 
 	// Get failed jobs
-	fmt.Printf("Fetching workflow jobs...\n")
-	jobs, err := c.getWorkflowJobs(workflow.ID)
+	record := c.summary.pullRequest(prNumber)
+	jobs, err := c.getWorkflowJobs(ctx, workflow.ID)
 	if err != nil {
+		record.Decision = "Failed to get the workflow jobs"
 		return fmt.Errorf("failed to get workflow jobs: %w", err)
 	}
-	fmt.Printf("Found %d total jobs\n", len(jobs))
 
 	// Find failed jobs
 	var failedJobs []Job
 	for _, job := range jobs {
 		if job.Conclusion == "failure" {
 			failedJobs = append(failedJobs, job)
-			fmt.Printf("  ❌ Failed job: %s (ID: %d)\n", job.Name, job.ID)
-		} else {
-			fmt.Printf("  ✅ Job: %s - %s\n", job.Name, job.Conclusion)
 		}
+		log.Debug("Workflow job", "job", job.Name, "job_id", job.ID, "conclusion", job.Conclusion)
 	}
 
 	if len(failedJobs) == 0 {
-		fmt.Printf("No failed jobs found for workflow run %d\n", workflow.ID)
+		log.Info("No failed jobs found", "jobs", len(jobs))
+		record.Decision = "No failed jobs found"
 		return nil
 	}
 
-	// Get logs for failed jobs
-	fmt.Printf("\nFetching logs for %d failed job(s)...\n", len(failedJobs))
-	var logSnippets []string
-	for _, job := range failedJobs {
-		fmt.Printf("  → Fetching logs for job '%s' (ID: %d)...\n", job.Name, job.ID)
-		logs, err := c.getJobLogs(job.ID)
+	// Read the workflow at the tested commit, for its job graph and the
+	// steps to reproduce failures
+	definition, err := c.getWorkflowDefinition(ctx, workflow)
+	if err != nil {
+		log.Warn("Failed to get the workflow definition", "path", workflow.Path, "error", err)
+	}
+
+	// Report the jobs that broke first, and list the jobs that only failed
+	// or were skipped because of them
+	failedJobs, casualties := definition.rootCauses(jobs)
+	for _, job := range casualties {
+		log.Info("Job is downstream of a failure", "job", job.Name, "job_id", job.ID, "conclusion", job.Conclusion)
+	}
+
+	// Find the last green run to diff failing logs against
+	var baselineJobs map[string]Job
+	if c.config.CompareWithLastSuccess {
+		branch := baseBranch(workflow, prNumber)
+		baselineJobs, err = c.getBaselineJobs(ctx, workflow, branch)
 		if err != nil {
-			fmt.Printf("    ⚠️  Warning: failed to get logs for job %d: %v\n", job.ID, err)
-			continue
+			log.Warn("Failed to find a successful run to compare with", "branch", branch, "error", err)
 		}
-		fmt.Printf("    → Retrieved %d bytes of logs\n", len(logs))
+	}
+
+	// Read test result artifacts while the logs are analyzed, so that slow
+	// logs using up the deadline do not leave the artifacts unread
+	var results []Failure
+	var resultsErr error
+	var artifactsRead sync.WaitGroup
+	if len(c.config.ArtifactPatterns) > 0 {
+		artifactsRead.Add(1)
+		go func() {
+			defer artifactsRead.Done()
+			results, resultsErr = c.getArtifactTestResults(ctx, workflow.ID)
+		}()
+	}
+
+	// Get logs for failed jobs
+	log.Info("Analyzing failed jobs", "jobs", len(failedJobs), "downstream", len(casualties))
+	report := &FailureReport{Workflow: workflow, PRNumber: prNumber, Casualties: casualties}
+	report.Jobs = c.analyzeJobs(ctx, failedJobs, workflow, baselineJobs)
+
+	// Show how to rerun each failing step
+	for i := range report.Jobs {
+		report.Jobs[i].Reproduction = definition.Reproduction(report.Jobs[i].Job, report.Jobs[i].Failures)
+	}
 
-		snippet := extractErrorSnippet(logs, 20)
-		if snippet != "" {
-			fmt.Printf("    → Extracted error snippet (%d chars)\n", len(snippet))
-			logSnippets = append(logSnippets, fmt.Sprintf("**Job: %s**\n```\n%s\n```", job.Name, snippet))
+	// Prefer machine-readable test results from artifacts over scraped lines
+	artifactsRead.Wait()
+	if len(c.config.ArtifactPatterns) > 0 {
+		if resultsErr != nil {
+			log.Warn("Failed to get test result artifacts", "error", resultsErr)
+		} else {
+			log.Info("Read test result artifacts", "failures", len(results))
 		}
+		report.setTestResults(results)
+	}
+
+	jobNames := make([]string, len(failedJobs))
+	for i, job := range failedJobs {
+		jobNames[i] = job.Name
 	}
+	report.Instructions = c.config.Instructions.For(workflow.Name, jobNames)
+	record.FailedJobs = jobNames
+	record.Failures = report.failureCount()
+
+	// Nothing taken from the logs leaves this point with secrets in it
+	report = report.redacted(c.redactor)
 
 	// Create comment
-	fmt.Printf("\nBuilding failure comment...\n")
-	comment := c.buildFailureComment(workflow, failedJobs, logSnippets)
-	fmt.Printf("Comment length: %d characters\n", len(comment))
+	comment := c.buildFailureComment(report)
+	log.Debug("Built failure comment", "length", len(comment))
 
-	fmt.Printf("Posting comment to PR #%d...\n", prNumber)
-	if err := c.createComment(prNumber, comment); err != nil {
+	commentURL, err := c.createComment(prNumber, comment)
+	if err != nil {
+		record.Decision = "Failed to post the failure comment"
 		return fmt.Errorf("failed to create comment: %w", err)
 	}
+	record.Action, record.CommentURL, record.Comment = ActionFailureComment, commentURL, comment
+	record.Decision = "Posted a failure comment"
+
+	log.Info("Posted failure comment", "url", commentURL)
+
+	// Point at failures on the lines the PR changed. The summary comment is
+	// already posted, so a failure here only costs the inline comments.
+	if c.config.InlineComments {
+		if err := c.createFailureReview(context.Background(), prNumber, workflow, report.sanitized()); err != nil {
+			log.Warn("Failed to post review comments", "error", err)
+		}
+	}
 
-	fmt.Printf("✅ Successfully posted failure comment to PR #%d\n", prNumber)
+	if c.config.CheckRun {
+		if err := c.publishCheckRun(context.Background(), prNumber, workflow, report); err != nil {
+			log.Warn("Failed to publish the check run", "error", err)
+		}
+	}
 	return nil
 }
 
+// analyzeJobs analyzes failed jobs concurrently, at most
+// Config.MaxConcurrentJobs at a time, and returns their reports in the
+// order of the jobs
+func (c *Client) analyzeJobs(ctx context.Context, jobs []Job, workflow *WorkflowRun, baselineJobs map[string]Job) []JobReport {
+	workers := c.config.MaxConcurrentJobs
+	if workers < 1 {
+		workers = 1
+	}
+
+	reports := make([]JobReport, len(jobs))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i, job := range jobs {
+		var baselineJob *Job
+		if j, ok := baselineJobs[job.Name]; ok {
+			baselineJob = &j
+		}
+
+		wg.Add(1)
+		go func(i int, job Job) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			reports[i] = c.analyzeJob(ctx, job, workflow, baselineJob)
+		}(i, job)
+	}
+	wg.Wait()
+	return reports
+}
+
+// analyzeJob gathers the annotations and log failures of a failed job.
+// When baselineJob is set, its passing log is used to pick novel lines. If
+// the context ends first, the report holds whatever was read and is marked
+// incomplete.
+func (c *Client) analyzeJob(ctx context.Context, job Job, workflow *WorkflowRun, baselineJob *Job) (jobReport JobReport) {
+	jobReport.Job = job
+	jobReport.LogURL = logURL(job, nil)
+	defer func() {
+		if ctx.Err() != nil {
+			jobReport.Incomplete = true
+		}
+	}()
+
+	log := c.logger.With("run_id", workflow.ID, "job_id", job.ID)
+	annotations, err := c.getJobAnnotations(ctx, job, workflow.HeadSHA)
+	if err != nil {
+		log.Warn("Failed to get annotations", "error", err)
+	}
+	defer func() { jobReport.addAnnotations(annotations) }()
+
+	var baseline LogBaseline
+	if baselineJob != nil {
+		if baseline, err = c.getLogBaseline(ctx, baselineJob.ID); err != nil {
+			log.Warn("Failed to get the logs of the passing job to compare with", "baseline_job_id", baselineJob.ID, "error", err)
+		}
+	}
+
+	logs, err := c.getJobLogs(ctx, job.ID, c.config.LogTailBytes)
+	if err != nil {
+		log.Warn("Failed to get logs", "error", err)
+		return jobReport
+	}
+	defer logs.Close()
+
+	counter := &countingReader{r: logs}
+	analysis, err := c.AnalyzeLog(counter, baseline)
+	if err != nil {
+		log.Warn("Failed to read logs", "error", err)
+	}
+
+	jobReport.Failures = analysis.Failures
+	jobReport.Snippet = analysis.Snippet
+	c.linkFailures(jobReport.Failures, workflow.HeadSHA)
+	// Line numbers only match the log viewer when the log was read from the start
+	if _, partial := logs.(logTail); !partial {
+		jobReport.LogURL = logURL(job, analysis.StepErrorLines)
+	}
+	log.Info("Analyzed job",
+		"job", job.Name,
+		"log_bytes", counter.n,
+		"annotations", len(annotations),
+		"failures", len(analysis.Failures),
+		"parsers", strings.Join(analysis.Parsers, ","),
+		"snippet_length", len(analysis.Snippet))
+	return jobReport
+}
+
+// AnalyzeLog normalizes a job log and extracts its failures, or an error
+// snippet, the way the log of a failed job is, with the client's parsers
+// and settings. When baseline is set, the snippet shows the lines that are
+// not in it.
+func (c *Client) AnalyzeLog(logs io.Reader, baseline LogBaseline) (LogAnalysis, error) {
+	return c.extractors.Analyze(logs, AnalyzeOptions{
+		Lines:    c.config.SnippetLines,
+		Keywords: c.config.ErrorKeywords,
+		Baseline: baseline,
+	})
+}
+
+// getLogBaseline fingerprints the logs of a passing job. The whole log is
+// read, as the tail of a failing log can reach back before the tail of the
+// passing one, and lines missing from a truncated baseline would all look
+// new.
+func (c *Client) getLogBaseline(ctx context.Context, jobID int64) (LogBaseline, error) {
+	logs, err := c.getJobLogs(ctx, jobID, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer logs.Close()
+	return NewLogBaseline(logs)
+}
+
+// baseBranch returns the base branch of the given pull request in a workflow run
+func baseBranch(workflow *WorkflowRun, prNumber int) string {
+	for _, pr := range workflow.PullRequests {
+		if pr.Number == prNumber {
+			return pr.Base.Ref
+		}
+	}
+	return ""
+}
+
 // handleSuccessfulWorkflow handles a successful workflow run
 func (c *Client) handleSuccessfulWorkflow(prNumber int, workflow *WorkflowRun) error {
-	fmt.Printf("\n--- Handling Successful Workflow ---\n")
-	fmt.Printf("Workflow: '%s'\n", workflow.Name)
-	fmt.Printf("PR: #%d\n", prNumber)
+	log := c.logger.With("pr", prNumber, "run_id", workflow.ID)
+	log.Info("Handling successful workflow", "workflow", workflow.Name)
 
-	comment := fmt.Sprintf("✅ **Workflow '%s' completed successfully!**\n\n[View workflow run](%s)",
-		workflow.Name, workflow.HTMLURL)
+	comment := c.renderComment(SuccessComment, &CommentData{
+		Workflow:    workflow,
+		PullRequest: prNumber,
+		Attempt:     max(workflow.RunAttempt, 1),
+	})
 
-	fmt.Printf("Building success comment...\n")
-	fmt.Printf("Comment length: %d characters\n", len(comment))
+	log.Debug("Built success comment", "length", len(comment))
 
-	fmt.Printf("Posting comment to PR #%d...\n", prNumber)
-	if err := c.createComment(prNumber, comment); err != nil {
+	record := c.summary.pullRequest(prNumber)
+	commentURL, err := c.createComment(prNumber, comment)
+	if err != nil {
+		record.Decision = "Failed to post the success comment"
 		return fmt.Errorf("failed to create comment: %w", err)
 	}
+	record.Action, record.CommentURL, record.Comment = ActionSuccessComment, commentURL, comment
+	record.Decision = "Posted a success comment"
 
-	fmt.Printf("✅ Successfully posted success comment to PR #%d\n", prNumber)
+	log.Info("Posted success comment", "url", commentURL)
+
+	if c.config.CheckRun {
+		if err := c.publishCheckRun(context.Background(), prNumber, workflow, nil); err != nil {
+			log.Warn("Failed to publish the check run", "error", err)
+		}
+	}
 	return nil
 }
 
-// getWorkflowJobs retrieves all jobs for a workflow run
-func (c *Client) getWorkflowJobs(runID int64) ([]Job, error) {
-	url := fmt.Sprintf("%s/repos/%s/actions/runs/%d/jobs", githubAPIURL, c.repository, runID)
-	fmt.Printf("  → API call: GET %s\n", url)
+// getWorkflowJobs retrieves all jobs for a workflow run, reading every page
+// of a large matrix
+func (c *Client) getWorkflowJobs(ctx context.Context, runID int64) ([]Job, error) {
+	var jobs []Job
+	for page := 1; ; page++ {
+		url := fmt.Sprintf("%s/repos/%s/actions/runs/%d/jobs?per_page=100&page=%d", c.baseURL, c.repository, runID, page)
+		var jobsResp JobsResponse
+		if err := c.getJSON(ctx, url, &jobsResp); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, jobsResp.Jobs...)
+		if len(jobsResp.Jobs) < 100 || len(jobs) >= jobsResp.TotalCount {
+			return jobs, nil
+		}
+	}
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// getJobLogs opens the logs of a specific job for streaming. The API
+// redirects to log storage; when the log there is larger than tail bytes
+// only its trailing bytes are fetched, and a tail of 0 fetches it whole. The
+// caller must close the returned reader.
+func (c *Client) getJobLogs(ctx context.Context, jobID int64, tail int64) (io.ReadCloser, error) {
+	url := fmt.Sprintf("%s/repos/%s/actions/jobs/%d/logs", c.baseURL, c.repository, jobID)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -268,150 +552,290 @@ func (c *Client) getWorkflowJobs(runID int64) ([]Job, error) {
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
-	resp, err := c.httpClient.Do(req)
+	// Follow the redirect by hand so the storage requests can carry a Range
+	noRedirect := *c.httpClient
+	noRedirect.CheckRedirect = func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	start := time.Now()
+	resp, err := noRedirect.Do(req)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
 
-	fmt.Printf("  → API response: %d\n", resp.StatusCode)
-	if resp.StatusCode != http.StatusOK {
+	c.logAPICall(req, resp.StatusCode, start)
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return resp.Body, nil
+	case http.StatusFound, http.StatusMovedPermanently, http.StatusSeeOther, http.StatusTemporaryRedirect:
+		resp.Body.Close()
+		location, err := resp.Location()
+		if err != nil {
+			return nil, err
+		}
+		return c.openLogTail(ctx, location.String(), tail)
+	default:
+		defer resp.Body.Close()
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("GitHub API error: %d - %s", resp.StatusCode, string(body))
 	}
+}
+
+// openLogTail downloads a job log from its pre-signed storage URL, asking for
+// only the last tail bytes when the log is larger than that. A backend that
+// ignores the Range header yields the full log.
+func (c *Client) openLogTail(ctx context.Context, url string, tail int64) (io.ReadCloser, error) {
+	var size int64 = -1
+	if tail > 0 {
+		size = c.probeContentLength(ctx, url)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	ranged := tail > 0 && size > tail
+	if ranged {
+		// Start one byte early so that dropping the first partial line keeps
+		// a line that happens to begin exactly at the tail boundary
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", size-tail-1))
+		c.logger.Debug("Fetching the tail of a log", "tail_bytes", tail, "size", size)
+	}
 
-	var jobsResp JobsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&jobsResp); err != nil {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
 		return nil, err
 	}
 
-	return jobsResp.Jobs, nil
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && ranged:
+		return dropFirstLine(resp.Body), nil
+	case resp.StatusCode == http.StatusOK:
+		if ranged {
+			c.logger.Debug("Log storage ignored the range request, reading the full log", "size", size)
+		}
+		return resp.Body, nil
+	default:
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("log download error: %d - %s", resp.StatusCode, string(body))
+	}
 }
 
-// getJobLogs retrieves logs for a specific job
-func (c *Client) getJobLogs(jobID int64) (string, error) {
-	url := fmt.Sprintf("%s/repos/%s/actions/jobs/%d/logs", githubAPIURL, c.repository, jobID)
-	fmt.Printf("    → API call: GET %s\n", url)
+// probeContentLength returns the size of the object at a storage URL, or -1
+// when it cannot be determined
+func (c *Client) probeContentLength(ctx context.Context, url string) int64 {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
+	if err != nil {
+		return -1
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return -1
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return -1
+	}
+	return resp.ContentLength
+}
 
-	req, err := http.NewRequest("GET", url, nil)
+// getJSON performs an authenticated GET request and decodes the JSON response into v
+func (c *Client) getJSON(ctx context.Context, url string, v interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return "", err
+		return err
 	}
 
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", err
+		return err
 	}
 	defer resp.Body.Close()
 
-	fmt.Printf("    → API response: %d\n", resp.StatusCode)
+	c.logAPICall(req, resp.StatusCode, start)
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("GitHub API error: %d - %s", resp.StatusCode, string(body))
+		return fmt.Errorf("GitHub API error: %d - %s", resp.StatusCode, string(body))
 	}
 
-	body, err := io.ReadAll(resp.Body)
-	if err != nil {
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// createComment creates a comment on a pull request and returns its URL
+func (c *Client) createComment(prNumber int, body string) (string, error) {
+	url := fmt.Sprintf("%s/repos/%s/issues/%d/comments", c.baseURL, c.repository, prNumber)
+	var created Comment
+	if err := c.sendJSON("POST", url, Comment{Body: body}, &created); err != nil {
 		return "", err
 	}
+	return created.HTMLURL, nil
+}
 
-	return string(body), nil
+// postJSON performs an authenticated POST request with v as its JSON body
+func (c *Client) postJSON(url string, v interface{}) error {
+	return c.sendJSON("POST", url, v, nil)
 }
 
-// createComment creates a comment on a pull request
-func (c *Client) createComment(prNumber int, body string) error {
-	url := fmt.Sprintf("%s/repos/%s/issues/%d/comments", githubAPIURL, c.repository, prNumber)
-	fmt.Printf("  → API call: POST %s\n", url)
+// sendJSON performs an authenticated request with v as its JSON body, and
+// decodes the JSON response into out unless it is nil
+func (c *Client) sendJSON(method, url string, v, out interface{}) error {
+	return c.sendJSONAs(c.token, method, url, v, out)
+}
 
-	comment := Comment{Body: body}
-	jsonData, err := json.Marshal(comment)
+// sendJSONAs is sendJSON authenticated with the given token. Every request
+// that writes to GitHub goes through here: in a dry run it is logged
+// instead of sent, and out is left as is.
+func (c *Client) sendJSONAs(token, method, url string, v, out interface{}) error {
+	jsonData, err := json.Marshal(v)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	if c.config.DryRun {
+		c.logger.Info("Dry run, not sending request", "method", method, "url", url, "body", string(jsonData))
+		return nil
+	}
+
+	req, err := http.NewRequest(method, url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return err
 	}
 
-	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.token))
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 	req.Header.Set("Content-Type", "application/json")
 
+	start := time.Now()
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return err
 	}
 	defer resp.Body.Close()
 
-	fmt.Printf("  → API response: %d\n", resp.StatusCode)
-	if resp.StatusCode != http.StatusCreated {
+	c.logAPICall(req, resp.StatusCode, start)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
 		body, _ := io.ReadAll(resp.Body)
 		return fmt.Errorf("GitHub API error: %d - %s", resp.StatusCode, string(body))
 	}
 
-	return nil
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
 }
 
-// buildFailureComment builds a formatted comment for workflow failures
-func (c *Client) buildFailureComment(workflow *WorkflowRun, failedJobs []Job, logSnippets []string) string {
-	var sb strings.Builder
+// logAPICall logs a GitHub API request and its response status
+func (c *Client) logAPICall(req *http.Request, status int, start time.Time) {
+	c.logger.Debug("API call",
+		"method", req.Method,
+		"url", req.URL.String(),
+		"status", status,
+		"duration", time.Since(start))
+}
 
-	sb.WriteString(fmt.Sprintf("❌ **Workflow '%s' failed**\n\n", workflow.Name))
-	sb.WriteString(fmt.Sprintf("[View workflow run](%s)\n\n", workflow.HTMLURL))
+// buildFailureComment builds a formatted comment for workflow failures,
+// with CI output neutralized and trimmed to fit GitHub's comment size limit
+func (c *Client) buildFailureComment(report *FailureReport) string {
+	return fitComment(report.sanitized(), c.renderFailureComment, maxCommentLength)
+}
 
-	sb.WriteString("**Failed Jobs:**\n")
-	for _, job := range failedJobs {
-		sb.WriteString(fmt.Sprintf("- %s\n", job.Name))
+// renderFailureComment renders the full failure comment for a report
+func (c *Client) renderFailureComment(report *FailureReport) string {
+	return c.renderComment(FailureComment, newCommentData(report))
+}
+
+// renderComment renders a comment with the configured templates, falling
+// back to the built-in ones if a custom template fails on this data
+func (c *Client) renderComment(kind string, data *CommentData) string {
+	body, err := c.templates.Render(kind, data)
+	if err == nil {
+		return body
 	}
-	sb.WriteString("\n")
+	c.logger.Warn("Failed to render the comment template, using the default", "kind", kind, "error", err)
+	body, err = DefaultTemplates().Render(kind, data)
+	if err != nil {
+		panic(fmt.Sprintf("built-in %s comment template failed: %v", kind, err))
+	}
+	return body
+}
 
-	if len(logSnippets) > 0 {
-		sb.WriteString("**Error Logs:**\n\n")
-		for _, snippet := range logSnippets {
-			sb.WriteString(snippet)
-			sb.WriteString("\n\n")
+// formatFailure renders a structured failure as a markdown list item
+func formatFailure(f Failure) string {
+	var sb strings.Builder
+	sb.WriteString("- ")
+	if f.TestID != "" {
+		sb.WriteString(inlineCode(f.TestID))
+	} else {
+		sb.WriteString(f.Parser)
+	}
+	if f.File != "" {
+		location := f.File
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", f.File, f.Line)
+		}
+		if f.URL != "" {
+			sb.WriteString(fmt.Sprintf(" at [%s](%s)", inlineCode(location), f.URL))
+		} else {
+			sb.WriteString(" at " + inlineCode(location))
 		}
 	}
+	if f.Duration > 0 {
+		sb.WriteString(fmt.Sprintf(" (%s)", f.Duration))
+	}
+	sb.WriteString("\n")
 
-	sb.WriteString("---\n")
-	sb.WriteString("@copilot Please review the failure above and fix the issues to make the workflow pass.\n")
-
+	details := f.Message
+	if f.Stack != "" {
+		details = appendLine(details, f.Stack)
+	}
+	if details != "" {
+		sb.WriteString(codeBlock(details, "  "))
+	}
 	return sb.String()
 }
 
-// extractErrorSnippet extracts the last N lines from logs, focusing on errors
-func extractErrorSnippet(logs string, lines int) string {
-	logLines := strings.Split(logs, "\n")
-
-	// Find lines containing error keywords
-	var errorLines []string
-	keywords := []string{"error", "failed", "failure", "exception", "fatal"}
-
-	for _, line := range logLines {
-		lower := strings.ToLower(line)
-		for _, keyword := range keywords {
-			if strings.Contains(lower, keyword) {
-				errorLines = append(errorLines, line)
-				break
-			}
+// errorKeywords are the words that mark a log line as describing an error
+var errorKeywords = keywordMatcher{"error", "failed", "failure", "exception", "fatal"}
+
+// keywordMatcher holds lowercase keywords that mark a log line as an error
+type keywordMatcher []string
+
+// newKeywordMatcher builds a matcher for the given keywords, or the
+// built-in error keywords when there are none
+func newKeywordMatcher(keywords []string) keywordMatcher {
+	var m keywordMatcher
+	for _, keyword := range keywords {
+		if keyword = strings.ToLower(strings.TrimSpace(keyword)); keyword != "" {
+			m = append(m, keyword)
 		}
 	}
+	if len(m) == 0 {
+		return errorKeywords
+	}
+	return m
+}
 
-	// If we found error lines, return those (limited to 'lines' count)
-	if len(errorLines) > 0 {
-		if len(errorLines) > lines {
-			return strings.Join(errorLines[len(errorLines)-lines:], "\n")
+// Match reports whether a log line mentions a keyword, ignoring case
+func (m keywordMatcher) Match(line string) bool {
+	lower := strings.ToLower(line)
+	for _, keyword := range m {
+		if strings.Contains(lower, keyword) {
+			return true
 		}
-		return strings.Join(errorLines, "\n")
 	}
+	return false
+}
 
-	// Otherwise, return last N lines
-	if len(logLines) > lines {
-		return strings.Join(logLines[len(logLines)-lines:], "\n")
+// extractErrorSnippet extracts the last N lines from logs, focusing on errors
+func extractErrorSnippet(logs string, lines int) string {
+	snippet := newSnippetCollector(lines, errorKeywords)
+	for _, line := range strings.Split(logs, "\n") {
+		snippet.Add(line)
 	}
-	return strings.Join(logLines, "\n")
+	return snippet.String()
 }
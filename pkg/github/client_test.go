@@ -1,10 +1,28 @@
 package github
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
 	"strings"
 	"testing"
+	"time"
 )
 
+func TestMain(m *testing.M) {
+	// Keep the clients' logs out of the test output
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+	os.Exit(m.Run())
+}
+
 func TestNewClient(t *testing.T) {
 	client := NewClient("test-token", "owner/repo")
 	if client == nil {
@@ -130,17 +148,20 @@ func TestBuildFailureComment(t *testing.T) {
 		HTMLURL: "https://github.com/owner/repo/actions/runs/123",
 	}
 
-	failedJobs := []Job{
-		{ID: 1, Name: "Build"},
-		{ID: 2, Name: "Test"},
-	}
-
-	logSnippets := []string{
-		"**Job: Build**\n```\nError: Build failed\n```",
-		"**Job: Test**\n```\nError: Tests failed\n```",
+	report := &FailureReport{
+		Workflow: workflow,
+		Jobs: []JobReport{
+			{Job: Job{ID: 1, Name: "Build"}, Snippet: "Error: Build failed"},
+			{
+				Job: Job{ID: 2, Name: "Test"},
+				Failures: []Failure{
+					{Parser: "go test", TestID: "TestAdd", File: "math_test.go", Line: 12, Message: "Add(2, 3) = 6; expected 5"},
+				},
+			},
+		},
 	}
 
-	comment := client.buildFailureComment(workflow, failedJobs, logSnippets)
+	comment := client.buildFailureComment(report)
 
 	if !strings.Contains(comment, "Test Workflow") {
 		t.Error("Comment should contain workflow name")
@@ -157,6 +178,15 @@ func TestBuildFailureComment(t *testing.T) {
 	if !strings.Contains(comment, workflow.HTMLURL) {
 		t.Error("Comment should contain workflow URL")
 	}
+	if !strings.Contains(comment, "Error: Build failed") {
+		t.Error("Comment should contain the heuristic snippet")
+	}
+	if !strings.Contains(comment, "`TestAdd` at `math_test.go:12`") {
+		t.Error("Comment should contain the structured failure location")
+	}
+	if !strings.Contains(comment, "Add(2, 3) = 6; expected 5") {
+		t.Error("Comment should contain the structured failure message")
+	}
 }
 
 func TestHandleWorkflowRun_NotCompleted(t *testing.T) {
@@ -260,3 +290,348 @@ func TestIsCopilotUser(t *testing.T) {
 		})
 	}
 }
+
+func TestGetJobLogs_Tail(t *testing.T) {
+	var logs strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&logs, "line %03d\n", i)
+	}
+	content := logs.String()
+
+	tests := []struct {
+		name        string
+		tailBytes   int64
+		ignoreRange bool
+		expected    string
+	}{
+		{
+			name:      "tail on a line boundary",
+			tailBytes: 27,
+			expected:  "line 098\nline 099\nline 100\n",
+		},
+		{
+			name:      "tail cuts a line",
+			tailBytes: 25,
+			expected:  "line 099\nline 100\n",
+		},
+		{
+			name:      "log smaller than tail",
+			tailBytes: 1 << 20,
+			expected:  content,
+		},
+		{
+			name:      "tail disabled",
+			tailBytes: 0,
+			expected:  content,
+		},
+		{
+			name:        "storage ignores range",
+			tailBytes:   25,
+			ignoreRange: true,
+			expected:    content,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var srv *httptest.Server
+			srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/repos/owner/repo/actions/jobs/1/logs":
+					http.Redirect(w, r, srv.URL+"/storage/1.txt", http.StatusFound)
+				case "/storage/1.txt":
+					if r.Header.Get("Authorization") != "" {
+						t.Errorf("Expected no token to be sent to log storage")
+					}
+					if tt.ignoreRange {
+						r.Header.Del("Range")
+					}
+					http.ServeContent(w, r, "1.txt", time.Time{}, strings.NewReader(content))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer srv.Close()
+
+			client := NewClient("test-token", "owner/repo")
+			client.baseURL = srv.URL
+
+			body, err := client.getJobLogs(context.Background(), 1, tt.tailBytes)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			defer body.Close()
+			var got bytes.Buffer
+			if _, err := io.Copy(&got, body); err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if got.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got.String())
+			}
+		})
+	}
+}
+
+func TestGetWorkflowJobs_Deadline(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Stall until the client gives up
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-token", "owner/repo")
+	client.baseURL = srv.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := client.getWorkflowJobs(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the deadline to be exceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the request to stop at the deadline, took %v", elapsed)
+	}
+}
+
+func TestGetWorkflowJobs_Pages(t *testing.T) {
+	var pages []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/actions/runs/123/jobs" || r.URL.Query().Get("per_page") != "100" {
+			http.NotFound(w, r)
+			return
+		}
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		resp := JobsResponse{TotalCount: 140}
+		count := 100
+		if page == "2" {
+			count = 40
+		}
+		for i := 0; i < count; i++ {
+			resp.Jobs = append(resp.Jobs, Job{ID: int64(len(pages)*1000 + i), Name: fmt.Sprintf("test (%d)", i)})
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-token", "owner/repo")
+	client.baseURL = srv.URL
+
+	jobs, err := client.getWorkflowJobs(context.Background(), 123)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(jobs) != 140 || !reflect.DeepEqual(pages, []string{"1", "2"}) {
+		t.Errorf("Expected 140 jobs from pages 1 and 2, got %d from %v", len(jobs), pages)
+	}
+}
+
+func TestHandleFailedWorkflow_ArtifactsAlongsideSlowLogs(t *testing.T) {
+	archive := buildZip(t, map[string]string{"reports/TEST-AppTest.xml": junitReport})
+	var comment Comment
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/actions/runs/1/jobs":
+			json.NewEncoder(w).Encode(JobsResponse{TotalCount: 1, Jobs: []Job{{ID: 2, Name: "test", Conclusion: "failure"}}})
+		case "/repos/owner/repo/actions/jobs/2/logs":
+			// Stall until the deadline
+			<-r.Context().Done()
+		case "/repos/owner/repo/actions/runs/1/artifacts":
+			fmt.Fprintf(w, `{"total_count":1,"artifacts":[{"id":1,"name":"test-results","size_in_bytes":%d,"archive_download_url":"%s/download/1"}]}`, len(archive), srv.URL)
+		case "/download/1":
+			w.Write(archive)
+		case "/repos/owner/repo/issues/7/comments":
+			json.NewDecoder(r.Body).Decode(&comment)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig()
+	config.AnalysisTimeout = 300 * time.Millisecond
+	config.ArtifactPatterns = []string{"test-results*"}
+	config.InlineComments = false
+	config.CheckRun = false
+	client := NewClientWithConfig("test-token", "owner/repo", config)
+	client.baseURL = srv.URL
+
+	if err := client.handleFailedWorkflow(7, &WorkflowRun{ID: 1, Name: "CI"}); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !strings.Contains(comment.Body, "testAdd") {
+		t.Errorf("Expected the artifact results in the comment despite the slow log, got:\n%s", comment.Body)
+	}
+}
+
+func TestAnalyzeJobs_PartialResults(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/actions/jobs/1/logs":
+			fmt.Fprint(w, "--- FAIL: TestFast (0.01s)\n    fast_test.go:3: boom\nFAIL\n")
+		case "/repos/owner/repo/actions/jobs/2/logs":
+			// Send one failure, then stall until the client gives up
+			fmt.Fprint(w, "--- FAIL: TestSlow (0.01s)\n    slow_test.go:7: stuck\n")
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig()
+	config.MaxConcurrentJobs = 2
+	client := NewClientWithConfig("test-token", "owner/repo", config)
+	client.baseURL = srv.URL
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	jobs := []Job{{ID: 2, Name: "slow"}, {ID: 1, Name: "fast"}}
+	reports := client.analyzeJobs(ctx, jobs, &WorkflowRun{ID: 100}, nil)
+
+	if len(reports) != 2 || reports[0].Job.Name != "slow" || reports[1].Job.Name != "fast" {
+		t.Fatalf("Expected reports in job order, got %+v", reports)
+	}
+	if !reports[0].Incomplete {
+		t.Error("Expected the stalled job to be marked incomplete")
+	}
+	if len(reports[0].Failures) != 1 || reports[0].Failures[0].TestID != "TestSlow" {
+		t.Errorf("Expected the failure read before the deadline, got %+v", reports[0].Failures)
+	}
+	if reports[1].Incomplete || len(reports[1].Failures) != 1 {
+		t.Errorf("Expected the fast job to be complete with one failure, got %+v", reports[1])
+	}
+}
+
+func TestBuildFailureComment_Incomplete(t *testing.T) {
+	client := NewClient("test-token", "owner/repo")
+	report := &FailureReport{
+		Workflow: &WorkflowRun{ID: 123, Name: "CI"},
+		Jobs: []JobReport{
+			{Job: Job{ID: 1, Name: "matrix (1)"}, Snippet: "Error: one", Incomplete: true},
+			{Job: Job{ID: 2, Name: "matrix (2)"}, Snippet: "Error: two"},
+		},
+	}
+
+	comment := client.buildFailureComment(report)
+
+	if !strings.Contains(comment, "- matrix (1) ⏱️\n") {
+		t.Error("Comment should flag the incomplete job")
+	}
+	if strings.Contains(comment, "matrix (2) ⏱️") {
+		t.Error("Comment should not flag the complete job")
+	}
+	if !strings.Contains(comment, "could not be fetched in time") {
+		t.Error("Comment should explain that results are partial")
+	}
+}
+
+func TestBuildFailureComment_Links(t *testing.T) {
+	client := NewClient("test-token", "owner/repo")
+	jobURL := "https://github.com/owner/repo/actions/runs/123/job/2"
+
+	report := &FailureReport{
+		Workflow: &WorkflowRun{ID: 123, Name: "CI", HTMLURL: "https://github.com/owner/repo/actions/runs/123"},
+		Jobs: []JobReport{{
+			Job:    Job{ID: 2, Name: "Test", HTMLURL: jobURL},
+			LogURL: jobURL + "#step:4:12",
+			Failures: []Failure{
+				{Parser: "tsc", File: "src/app.ts", Line: 3, Message: "oops", URL: "https://github.com/owner/repo/blob/abc123/src/app.ts#L3"},
+			},
+		}},
+	}
+
+	comment := client.buildFailureComment(report)
+
+	expected := "- [Test](" + jobURL + ") ([failing step](" + jobURL + "#step:4:12))\n"
+	if !strings.Contains(comment, expected) {
+		t.Errorf("Expected the job linked to its page and failing step, got:\n%s", comment)
+	}
+	if !strings.Contains(comment, "at [`src/app.ts:3`](https://github.com/owner/repo/blob/abc123/src/app.ts#L3)") {
+		t.Errorf("Expected the failure linked to its file, got:\n%s", comment)
+	}
+}
+
+func TestHandleWorkflowRun_DryRun(t *testing.T) {
+	var writes []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "GET" {
+			writes = append(writes, r.Method+" "+r.URL.Path)
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprint(w, `{}`)
+			return
+		}
+		switch r.URL.Path {
+		case "/repos/owner/repo/pulls/7":
+			fmt.Fprint(w, `{"number": 7, "user": {"login": "copilot", "type": "Bot"}}`)
+		case "/repos/owner/repo/actions/runs":
+			fmt.Fprint(w, `{"workflow_runs": []}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig()
+	config.DryRun = true
+	client := NewClientWithConfig("test-token", "owner/repo", config)
+	client.baseURL = srv.URL
+
+	event := &WorkflowRunEvent{WorkflowRun: WorkflowRun{
+		ID: 1, WorkflowID: 2, Name: "CI", HeadSHA: "abc123", Status: "completed", Conclusion: "success",
+		PullRequests: []PullRequest{{Number: 7}},
+	}}
+	if err := client.HandleWorkflowRun(event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if len(writes) != 0 {
+		t.Errorf("Expected no writes in a dry run, got %v", writes)
+	}
+	record := client.Summary().PullRequests[0]
+	if record.Action != ActionSuccessComment || record.CommentURL != "" || !strings.Contains(record.Comment, "CI") {
+		t.Errorf("Expected the success comment rendered but not posted, got %+v", record)
+	}
+	if markdown := client.Summary().Markdown(); !strings.Contains(markdown, "Dry run") || !strings.Contains(markdown, "success (not posted)") {
+		t.Errorf("Expected the summary to mark the dry run, got:\n%s", markdown)
+	}
+}
+
+func TestAnalyzeLog(t *testing.T) {
+	logs := "--- FAIL: TestAdd (0.00s)\n    math_test.go:12: error: got 6\nFAIL\nwarning: flaky\n"
+
+	tests := []struct {
+		name            string
+		parsers         []string
+		keywords        []string
+		expectedParsers []string
+		expectedSnippet string
+	}{
+		{name: "all parsers", expectedParsers: []string{"go test"}},
+		{name: "selected parsers", parsers: []string{"jest"}, expectedSnippet: "    math_test.go:12: error: got 6"},
+		{name: "custom keywords", parsers: []string{"jest"}, keywords: []string{"warning"}, expectedSnippet: "warning: flaky"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultConfig()
+			config.Parsers = tt.parsers
+			config.ErrorKeywords = tt.keywords
+			client := NewClientWithConfig("", "", config)
+
+			analysis, err := client.AnalyzeLog(strings.NewReader(logs), nil)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !reflect.DeepEqual(analysis.Parsers, tt.expectedParsers) || analysis.Snippet != tt.expectedSnippet {
+				t.Errorf("Expected parsers %v and snippet %q, got %v and %q", tt.expectedParsers, tt.expectedSnippet, analysis.Parsers, analysis.Snippet)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package github
+
+import (
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// Config holds the settings that tune how the client gathers and reports failures
+type Config struct {
+	// ArtifactPatterns are path.Match globs of run artifact names that hold
+	// JUnit XML or go test -json reports. No artifacts are read when empty.
+	ArtifactPatterns []string
+	// MaxArtifactBytes skips artifacts larger than this size
+	MaxArtifactBytes int64
+	// CompareWithLastSuccess diffs failing logs against the same job in the
+	// last successful run on the base branch to pick the snippet lines
+	CompareWithLastSuccess bool
+	// LogTailBytes limits job log downloads to this many trailing bytes,
+	// where failures almost always are. Zero downloads whole logs.
+	LogTailBytes int64
+	// SnippetLines is how many log lines the error snippet of a job keeps
+	// when no parser recognizes its failures
+	SnippetLines int
+	// ErrorKeywords are the words, matched ignoring case, that mark a log
+	// line as an error for the snippet and the failing step link. The
+	// built-in ones are used when empty.
+	ErrorKeywords []string
+	// Parsers limits the built-in extractors run over job logs to these
+	// names, e.g. "go test". All of them run when empty.
+	Parsers []string
+	// MaxConcurrentJobs bounds how many failed jobs are analyzed at once
+	MaxConcurrentJobs int
+	// AnalysisTimeout is the overall deadline for gathering failure details.
+	// Jobs not finished by then are reported with what was read so far.
+	// Zero means no deadline.
+	AnalysisTimeout time.Duration
+	// RedactPatterns are extra patterns for secrets to remove from log text,
+	// on top of the built-in rules. A group named "secret" limits the
+	// replacement to that group.
+	RedactPatterns []*regexp.Regexp
+	// RedactHighEntropy also removes random-looking tokens that no rule matches
+	RedactHighEntropy bool
+	// Instructions is repository guidance added to failure comments
+	Instructions *Instructions
+	// Templates renders comments. The built-in templates are used when nil.
+	Templates *Templates
+	// InlineComments also posts a review with a comment on each line of the
+	// pull request's diff that a failure points at
+	InlineComments bool
+	// CheckRun also publishes the state of the loop as a "Copilot Loop"
+	// check run on the pull request's head commit
+	CheckRun bool
+	// ChecksToken authenticates the requests that write check runs, which
+	// GitHub only accepts from GitHub App tokens such as the Actions
+	// GITHUB_TOKEN. The client's token is used when empty.
+	ChecksToken string
+	// DryRun gathers and renders everything as usual but sends no request
+	// that writes to GitHub; the requests are logged instead
+	DryRun bool
+	// HTTPClient sends the client's requests. A plain http.Client is used
+	// when nil.
+	HTTPClient *http.Client
+	// Logger receives the client's logs. slog.Default() is used when nil.
+	Logger *slog.Logger
+}
+
+// DefaultConfig returns the configuration used when none is given
+func DefaultConfig() Config {
+	return Config{
+		MaxArtifactBytes:       50 << 20,
+		CompareWithLastSuccess: true,
+		LogTailBytes:           16 << 20,
+		SnippetLines:           20,
+		MaxConcurrentJobs:      4,
+		AnalysisTimeout:        5 * time.Minute,
+		RedactHighEntropy:      true,
+		InlineComments:         true,
+		CheckRun:               true,
+	}
+}
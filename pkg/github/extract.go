@@ -0,0 +1,203 @@
+package github
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Failure is a single structured failure recognized in a job log
+type Failure struct {
+	Parser  string `json:"parser"`
+	TestID  string `json:"test_id,omitempty"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+	Stack   string `json:"stack,omitempty"`
+	// Duration is how long the test ran, when the source reports it
+	Duration time.Duration `json:"duration,omitempty"`
+	// URL links to the failing location in the repository, when known
+	URL string `json:"url,omitempty"`
+}
+
+// Extractor recognizes the failures reported by one tool in a job log
+type Extractor interface {
+	// Name identifies the extractor, e.g. "go test" or "pytest"
+	Name() string
+	// NewParser returns a fresh parser for a single log
+	NewParser() Parser
+}
+
+// Parser consumes a normalized log one line at a time
+type Parser interface {
+	// Feed processes the next log line
+	Feed(line string)
+	// Failures returns the failures recognized once the whole log was fed
+	Failures() []Failure
+}
+
+// LogAnalysis is the result of running a Registry over a job log
+type LogAnalysis struct {
+	// Parsers lists the extractors that recognized at least one failure
+	Parsers []string `json:"parsers,omitempty"`
+	// Failures holds the structured failures, in extractor order
+	Failures []Failure `json:"failures,omitempty"`
+	// Snippet holds the heuristic error snippet when no extractor matched
+	Snippet string `json:"snippet,omitempty"`
+	// StepErrorLines holds, for each section of the log that the log viewer
+	// shows as a step, the line within it of its first error line, or 0.
+	// Section 0 is "Set up job" and each later one starts at a
+	// "##[group]Run" header.
+	StepErrorLines []int `json:"step_error_lines,omitempty"`
+}
+
+// Registry holds the extractors that are run over job logs
+type Registry struct {
+	extractors []Extractor
+}
+
+// NewRegistry creates a registry with the given extractors
+func NewRegistry(extractors ...Extractor) *Registry {
+	return &Registry{extractors: extractors}
+}
+
+// DefaultRegistry creates a registry with all built-in extractors
+func DefaultRegistry() *Registry {
+	return NewRegistry(
+		goTestExtractor{},
+		jestExtractor{},
+		tscExtractor{},
+		eslintExtractor{},
+		pytestExtractor{},
+		junitExtractor{},
+		cargoExtractor{},
+		stackTraceExtractor{},
+	)
+}
+
+// Register adds an extractor to the registry
+func (r *Registry) Register(e Extractor) {
+	r.extractors = append(r.extractors, e)
+}
+
+// Names returns the names of the registered extractors
+func (r *Registry) Names() []string {
+	names := make([]string, 0, len(r.extractors))
+	for _, e := range r.extractors {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+// Select returns a registry with only the named extractors, in registry
+// order. Naming an extractor that is not registered is an error.
+func (r *Registry) Select(names []string) (*Registry, error) {
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	selected := &Registry{}
+	for _, e := range r.extractors {
+		if wanted[e.Name()] {
+			selected.extractors = append(selected.extractors, e)
+			delete(wanted, e.Name())
+		}
+	}
+	for _, name := range names {
+		if wanted[name] {
+			return nil, fmt.Errorf("unknown parser %q, expected one of: %s", name, strings.Join(r.Names(), ", "))
+		}
+	}
+	return selected, nil
+}
+
+// AnalyzeOptions tunes how a log is analyzed
+type AnalyzeOptions struct {
+	// Lines is the maximum number of lines in the heuristic snippet
+	Lines int
+	// Keywords mark the log lines that describe an error, matched ignoring
+	// case, for the snippet and the failing step. The built-in ones are used
+	// when empty.
+	Keywords []string
+	// Baseline, when set, makes the snippet show the lines that never
+	// appeared in a passing log of the same job
+	Baseline LogBaseline
+}
+
+// Analyze streams a job log through every registered extractor. When none of
+// them recognizes a failure, the result falls back to a heuristic snippet.
+// Memory use is bounded by the snippet size and per-parser failure limits,
+// not by the size of the log. If reading the log fails partway, the analysis
+// of the lines read so far is returned along with the error.
+func (r *Registry) Analyze(logs io.Reader, opts AnalyzeOptions) (LogAnalysis, error) {
+	parsers := make([]Parser, len(r.extractors))
+	for i, e := range r.extractors {
+		parsers[i] = e.NewParser()
+	}
+
+	keywords := newKeywordMatcher(opts.Keywords)
+	snippet := newSnippetCollector(opts.Lines, keywords)
+	steps := newStepLocator(keywords)
+	var novelty *noveltyCollector
+	if opts.Baseline != nil {
+		novelty = newNoveltyCollector(opts.Baseline, opts.Lines, keywords)
+	}
+
+	err := forEachLine(logs, func(raw string) {
+		line := normalizeLogLine(raw)
+		snippet.Add(line)
+		steps.Add(line)
+		if novelty != nil {
+			novelty.Add(line)
+		}
+		for _, p := range parsers {
+			p.Feed(line)
+		}
+	})
+
+	analysis := LogAnalysis{StepErrorLines: steps.ErrorLines()}
+	for i, p := range parsers {
+		failures := p.Failures()
+		if len(failures) == 0 {
+			continue
+		}
+		analysis.Parsers = append(analysis.Parsers, r.extractors[i].Name())
+		analysis.Failures = append(analysis.Failures, failures...)
+	}
+	analysis.Failures = mergeStackTraces(analysis.Failures)
+
+	if len(analysis.Failures) == 0 {
+		if novelty != nil {
+			analysis.Snippet = novelty.String()
+		}
+		if analysis.Snippet == "" {
+			analysis.Snippet = snippet.String()
+		}
+	}
+	return analysis, err
+}
+
+var (
+	logTimestampRegex = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?Z ?`)
+	ansiEscapeRegex   = regexp.MustCompile(`\x1b\[[0-9;?]*[A-Za-z]`)
+)
+
+// normalizeLogLine strips the runner timestamp prefix, ANSI color codes and
+// trailing carriage returns from a raw job log line. The regexes only run on
+// lines that can match them, as this is called for every line of every log.
+func normalizeLogLine(line string) string {
+	line = strings.TrimPrefix(line, "\ufeff")
+	if len(line) > 10 && line[4] == '-' && line[10] == 'T' {
+		if loc := logTimestampRegex.FindStringIndex(line); loc != nil {
+			line = line[loc[1]:]
+		}
+	}
+	if strings.IndexByte(line, '\x1b') >= 0 {
+		line = ansiEscapeRegex.ReplaceAllString(line, "")
+	}
+	return strings.TrimRight(line, "\r")
+}
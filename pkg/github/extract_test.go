@@ -0,0 +1,120 @@
+package github
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// analyze runs a registry over a log held in a string
+func analyze(t *testing.T, r *Registry, logs string, opts AnalyzeOptions) LogAnalysis {
+	t.Helper()
+	analysis, err := r.Analyze(strings.NewReader(logs), opts)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return analysis
+}
+
+func TestNormalizeLogLine(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		expected string
+	}{
+		{name: "timestamp prefix", line: "2024-05-01T12:34:56.7890123Z --- FAIL: TestAdd (0.00s)", expected: "--- FAIL: TestAdd (0.00s)"},
+		{name: "ansi colors", line: "\x1b[31mFAIL\x1b[0m src/a.test.ts", expected: "FAIL src/a.test.ts"},
+		{name: "carriage return", line: "error: boom\r", expected: "error: boom"},
+		{name: "byte order mark", line: "\ufeff2024-05-01T12:34:56Z hello", expected: "hello"},
+		{name: "plain line", line: "plain", expected: "plain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := normalizeLogLine(tt.line)
+			if result != tt.expected {
+				t.Errorf("normalizeLogLine(%q) = %q, expected %q", tt.line, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestRegistryAnalyze_StructuredFailures(t *testing.T) {
+	logs := "2024-05-01T12:34:56.0000000Z --- FAIL: TestAdd (0.00s)\n" +
+		"2024-05-01T12:34:56.0000000Z     math_test.go:47: Add(2, 3) = 6; expected 5\n" +
+		"2024-05-01T12:34:56.0000000Z FAIL\n"
+
+	analysis := analyze(t, DefaultRegistry(), logs, AnalyzeOptions{Lines: 20})
+
+	if !reflect.DeepEqual(analysis.Parsers, []string{"go test"}) {
+		t.Errorf("Expected only the go test parser to match, got %v", analysis.Parsers)
+	}
+	if len(analysis.Failures) != 1 {
+		t.Fatalf("Expected 1 failure, got %d", len(analysis.Failures))
+	}
+	if analysis.Snippet != "" {
+		t.Errorf("Expected no fallback snippet, got %q", analysis.Snippet)
+	}
+}
+
+func TestRegistryAnalyze_FallbackSnippet(t *testing.T) {
+	logs := "2024-05-01T12:34:56Z Line 1\n2024-05-01T12:34:56Z ERROR: Something went wrong\n2024-05-01T12:34:56Z Line 3"
+
+	analysis := analyze(t, DefaultRegistry(), logs, AnalyzeOptions{Lines: 20})
+
+	if len(analysis.Failures) != 0 {
+		t.Errorf("Expected no structured failures, got %v", analysis.Failures)
+	}
+	if analysis.Snippet != "ERROR: Something went wrong" {
+		t.Errorf("Expected fallback snippet without timestamps, got %q", analysis.Snippet)
+	}
+}
+
+func TestRegistryAnalyze_Keywords(t *testing.T) {
+	logs := "Line 1\nERROR: not this one\npanic: Boom\nLine 4"
+
+	tests := []struct {
+		name     string
+		keywords []string
+		expected string
+	}{
+		{name: "built-in keywords", expected: "ERROR: not this one"},
+		{name: "custom keywords ignore case", keywords: []string{"PANIC"}, expected: "panic: Boom"},
+		{name: "blank keywords fall back", keywords: []string{" "}, expected: "ERROR: not this one"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis := analyze(t, DefaultRegistry(), logs, AnalyzeOptions{Lines: 20, Keywords: tt.keywords})
+			if analysis.Snippet != tt.expected {
+				t.Errorf("Expected snippet %q, got %q", tt.expected, analysis.Snippet)
+			}
+		})
+	}
+}
+
+func TestRegistrySelect(t *testing.T) {
+	registry := NewRegistry(goTestExtractor{}, tscExtractor{}, eslintExtractor{})
+
+	selected, err := registry.Select([]string{"eslint", "go test"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if !reflect.DeepEqual(selected.Names(), []string{"go test", "eslint"}) {
+		t.Errorf("Expected the selected extractors in registry order, got %v", selected.Names())
+	}
+
+	if _, err := registry.Select([]string{"tsc", "mocha"}); err == nil || !strings.Contains(err.Error(), `"mocha"`) {
+		t.Errorf("Expected an error naming the unknown parser, got %v", err)
+	}
+}
+
+func TestRegistryRegister(t *testing.T) {
+	registry := NewRegistry()
+	registry.Register(tscExtractor{})
+	registry.Register(eslintExtractor{})
+
+	if !reflect.DeepEqual(registry.Names(), []string{"tsc", "eslint"}) {
+		t.Errorf("Expected registered extractors in order, got %v", registry.Names())
+	}
+}
@@ -0,0 +1,81 @@
+package github
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+var (
+	fixturePullRequestPath = regexp.MustCompile(`^/repos/[^/]+/[^/]+/pulls/(\d+)$`)
+	fixtureJobsPath        = regexp.MustCompile(`^/repos/[^/]+/[^/]+/actions/runs/\d+/jobs$`)
+	fixtureJobLogsPath     = regexp.MustCompile(`^/repos/[^/]+/[^/]+/actions/jobs/(\d+)/logs$`)
+)
+
+// Fixtures stand in for the GitHub API with saved responses, so that the
+// comments for a workflow run can be rendered offline. As an
+// http.RoundTripper it answers:
+//
+//   - the pull requests of the run, as opened by Copilot
+//   - the jobs of the run, with Jobs
+//   - the log of a job, with the file named after the job ID, with a .txt or
+//     .log extension, in LogsDir
+//
+// Every other request gets a 404 without leaving the process; the client
+// goes on without what it asked for, as it does when the API fails.
+type Fixtures struct {
+	// Jobs is a saved response of the list jobs for a workflow run API
+	Jobs []byte
+	// LogsDir holds the saved logs of the failed jobs
+	LogsDir string
+}
+
+// RoundTrip answers a request from the fixtures
+func (f *Fixtures) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Method != "GET" {
+		return fixtureResponse(req, http.StatusNotFound, nil), nil
+	}
+
+	switch path := req.URL.Path; {
+	case fixturePullRequestPath.MatchString(path):
+		number := fixturePullRequestPath.FindStringSubmatch(path)[1]
+		body := fmt.Sprintf(`{"number": %s, "user": {"login": "copilot", "type": "Bot"}}`, number)
+		return fixtureResponse(req, http.StatusOK, []byte(body)), nil
+	case fixtureJobsPath.MatchString(path) && f.Jobs != nil:
+		return fixtureResponse(req, http.StatusOK, f.Jobs), nil
+	case fixtureJobLogsPath.MatchString(path) && f.LogsDir != "":
+		jobID := fixtureJobLogsPath.FindStringSubmatch(path)[1]
+		for _, ext := range []string{".txt", ".log"} {
+			data, err := os.ReadFile(filepath.Join(f.LogsDir, jobID+ext))
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			return fixtureResponse(req, http.StatusOK, data), nil
+		}
+	}
+	return fixtureResponse(req, http.StatusNotFound, nil), nil
+}
+
+// fixtureResponse builds the response to a request
+func fixtureResponse(req *http.Request, status int, body []byte) *http.Response {
+	if body == nil {
+		body = []byte(`{"message": "Not Found"}`)
+	}
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,78 @@
+package github
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFixtures(t *testing.T) {
+	logsDir := t.TempDir()
+	logs := "--- FAIL: TestAdd (0.00s)\n    math_test.go:12: Add(2, 3) = 6, want 5\nFAIL\n"
+	if err := os.WriteFile(filepath.Join(logsDir, "901.log"), []byte(logs), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	fixtures := &Fixtures{
+		Jobs:    []byte(`{"jobs": [{"id": 901, "name": "test", "conclusion": "failure"}, {"id": 902, "name": "lint", "conclusion": "success"}]}`),
+		LogsDir: logsDir,
+	}
+
+	config := DefaultConfig()
+	config.HTTPClient = &http.Client{Transport: fixtures}
+	config.DryRun = true
+	client := NewClientWithConfig("", "octo/app", config)
+
+	event := &WorkflowRunEvent{WorkflowRun: WorkflowRun{
+		ID: 55, WorkflowID: 2, Name: "CI", HeadSHA: "abc123", Status: "completed", Conclusion: "failure",
+		PullRequests: []PullRequest{{Number: 7}},
+	}}
+	if err := client.HandleWorkflowRun(event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	record := client.Summary().PullRequests[0]
+	if !record.Copilot || record.Action != ActionFailureComment {
+		t.Fatalf("Expected a failure comment for a Copilot PR, got %+v", record)
+	}
+	for _, expected := range []string{"Workflow 'CI' failed", "`TestAdd` at `math_test.go:12`", "Add(2, 3) = 6, want 5"} {
+		if !strings.Contains(record.Comment, expected) {
+			t.Errorf("Expected comment to contain %q, got:\n%s", expected, record.Comment)
+		}
+	}
+	if strings.Contains(record.Comment, "lint") {
+		t.Errorf("Expected only the failed job in the comment, got:\n%s", record.Comment)
+	}
+}
+
+func TestFixtures_RoundTrip(t *testing.T) {
+	fixtures := &Fixtures{}
+
+	tests := []struct {
+		name           string
+		method         string
+		url            string
+		expectedStatus int
+	}{
+		{name: "pull request", method: "GET", url: "https://api.github.com/repos/o/r/pulls/3", expectedStatus: http.StatusOK},
+		{name: "jobs not given", method: "GET", url: "https://api.github.com/repos/o/r/actions/runs/1/jobs", expectedStatus: http.StatusNotFound},
+		{name: "log not given", method: "GET", url: "https://api.github.com/repos/o/r/actions/jobs/1/logs", expectedStatus: http.StatusNotFound},
+		{name: "writes", method: "POST", url: "https://api.github.com/repos/o/r/issues/3/comments", expectedStatus: http.StatusNotFound},
+		{name: "other hosts", method: "GET", url: "https://example.com/", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, tt.url, nil)
+			resp, err := fixtures.RoundTrip(req)
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.expectedStatus {
+				t.Errorf("Expected status %d, got %d", tt.expectedStatus, resp.StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package github
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Instructions is repository guidance for Copilot, added to failure
+// comments. It is read from a YAML file such as:
+//
+//	instructions: |
+//	  Run `go test ./...` before pushing.
+//	rules:
+//	  - workflow: CI
+//	    job: "lint*"
+//	    instructions: |
+//	      Run `golangci-lint run` locally; do not edit generated files.
+type Instructions struct {
+	// General applies to every failure
+	General string `yaml:"instructions"`
+	// Rules apply to failures of matching workflows and jobs
+	Rules []InstructionRule `yaml:"rules"`
+}
+
+// InstructionRule is guidance for the failures of some workflows or jobs.
+// Workflow and Job are path.Match patterns; an empty pattern matches all.
+type InstructionRule struct {
+	Workflow     string `yaml:"workflow"`
+	Job          string `yaml:"job"`
+	Instructions string `yaml:"instructions"`
+}
+
+// LoadInstructions reads repository instructions from a YAML file. A missing
+// file yields no instructions.
+func LoadInstructions(file string) (*Instructions, error) {
+	data, err := os.ReadFile(file)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return ParseInstructions(data)
+}
+
+// ParseInstructions parses and validates the contents of an instructions file
+func ParseInstructions(data []byte) (*Instructions, error) {
+	var instructions Instructions
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&instructions); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+
+	for i, rule := range instructions.Rules {
+		if strings.TrimSpace(rule.Instructions) == "" {
+			return nil, fmt.Errorf("rule %d: instructions are required", i+1)
+		}
+		for _, pattern := range []string{rule.Workflow, rule.Job} {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("rule %d: invalid pattern %q: %w", i+1, pattern, err)
+			}
+		}
+	}
+	return &instructions, nil
+}
+
+// For returns the guidance for a failure of the given workflow and failed
+// jobs: the general instructions followed by every matching rule, in file
+// order
+func (i *Instructions) For(workflow string, jobs []string) string {
+	if i == nil {
+		return ""
+	}
+
+	var parts []string
+	if general := strings.TrimSpace(i.General); general != "" {
+		parts = append(parts, general)
+	}
+	for _, rule := range i.Rules {
+		if rule.matches(workflow, jobs) {
+			parts = append(parts, strings.TrimSpace(rule.Instructions))
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// matches reports whether the rule applies to the workflow and any of the jobs
+func (r InstructionRule) matches(workflow string, jobs []string) bool {
+	if !matchPattern(r.Workflow, workflow) {
+		return false
+	}
+	if r.Job == "" {
+		return true
+	}
+	for _, job := range jobs {
+		if matchPattern(r.Job, job) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPattern reports whether name matches a path.Match pattern, where an
+// empty pattern matches everything
+func matchPattern(pattern, name string) bool {
+	if pattern == "" {
+		return true
+	}
+	ok, _ := path.Match(pattern, name)
+	return ok
+}
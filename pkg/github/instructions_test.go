@@ -0,0 +1,124 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const sampleInstructions = `
+instructions: |
+  Run ` + "`go test ./...`" + ` before pushing.
+rules:
+  - workflow: CI
+    job: "lint*"
+    instructions: Do not edit files under gen/.
+  - workflow: Release
+    instructions: Release builds need GOOS=linux.
+  - job: "test (*)"
+    instructions: Matrix tests run on Go 1.21 and 1.22.
+`
+
+func TestInstructionsFor(t *testing.T) {
+	instructions, err := ParseInstructions([]byte(sampleInstructions))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		workflow string
+		jobs     []string
+		expected string
+	}{
+		{
+			name:     "general only",
+			workflow: "CI",
+			jobs:     []string{"build"},
+			expected: "Run `go test ./...` before pushing.",
+		},
+		{
+			name:     "job rule",
+			workflow: "CI",
+			jobs:     []string{"build", "lint-go"},
+			expected: "Run `go test ./...` before pushing.\n\nDo not edit files under gen/.",
+		},
+		{
+			name:     "workflow and job rules",
+			workflow: "Release",
+			jobs:     []string{"test (1.22)"},
+			expected: "Run `go test ./...` before pushing.\n\nRelease builds need GOOS=linux.\n\nMatrix tests run on Go 1.21 and 1.22.",
+		},
+		{
+			name:     "job rule for another workflow",
+			workflow: "Release",
+			jobs:     []string{"lint"},
+			expected: "Run `go test ./...` before pushing.\n\nRelease builds need GOOS=linux.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := instructions.For(tt.workflow, tt.jobs); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestParseInstructions_Invalid(t *testing.T) {
+	tests := []struct {
+		name      string
+		data      string
+		expectErr string
+	}{
+		{name: "unknown field", data: "instruction: typo", expectErr: "field instruction not found"},
+		{name: "empty rule", data: "rules:\n  - job: lint\n", expectErr: "rule 1: instructions are required"},
+		{name: "bad pattern", data: "rules:\n  - job: \"[\"\n    instructions: x\n", expectErr: "invalid pattern"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseInstructions([]byte(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+				t.Errorf("Expected error containing %q, got %v", tt.expectErr, err)
+			}
+		})
+	}
+}
+
+func TestLoadInstructions(t *testing.T) {
+	dir := t.TempDir()
+
+	instructions, err := LoadInstructions(filepath.Join(dir, "missing.yml"))
+	if err != nil || instructions.For("CI", nil) != "" {
+		t.Errorf("Expected no instructions for a missing file, got %v, %v", instructions, err)
+	}
+
+	file := filepath.Join(dir, "instructions.yml")
+	if err := os.WriteFile(file, []byte("instructions: Run make test."), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	instructions, err = LoadInstructions(file)
+	if err != nil || instructions.For("CI", nil) != "Run make test." {
+		t.Errorf("Expected the file's instructions, got %v, %v", instructions, err)
+	}
+}
+
+func TestBuildFailureComment_Instructions(t *testing.T) {
+	client := NewClient("test-token", "owner/repo")
+	report := &FailureReport{
+		Workflow:     &WorkflowRun{ID: 1, Name: "CI"},
+		Jobs:         []JobReport{{Job: Job{Name: "build"}, Snippet: "Error: boom"}},
+		Instructions: "Run `make test` locally.",
+	}
+
+	comment := client.buildFailureComment(report)
+
+	copilot := strings.LastIndex(comment, "@copilot")
+	guidance := strings.Index(comment, "**Repository guidance:**\n\nRun `make test` locally.")
+	if guidance < 0 || guidance < copilot || guidance < strings.Index(comment, untrustedEnd) {
+		t.Errorf("Expected the guidance after the request to Copilot, got:\n%s", comment)
+	}
+}
@@ -0,0 +1,182 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxCommentLength is the largest comment body GitHub accepts. Lengths are
+// measured in bytes, which never undercounts characters.
+const maxCommentLength = 65536
+
+// noLimit leaves a kind of content untrimmed
+const noLimit = -1
+
+// trimLimits bounds how much of each kind of content a comment shows
+type trimLimits struct {
+	snippetLines int // trailing lines of each log snippet
+	detailLines  int // leading lines of each failure message and stack
+	failures     int // failures per job and test results
+	jobs         int // failed jobs
+}
+
+// trimLevels are tried in order until the rendered comment fits. Each level
+// cuts deeper into the least important content first: log snippets, then
+// failure details, then whole failures, and finally the list of failed jobs.
+var trimLevels = []trimLimits{
+	{snippetLines: 10, detailLines: noLimit, failures: noLimit, jobs: noLimit},
+	{snippetLines: 5, detailLines: noLimit, failures: noLimit, jobs: noLimit},
+	{snippetLines: 5, detailLines: 8, failures: noLimit, jobs: noLimit},
+	{snippetLines: 0, detailLines: 8, failures: noLimit, jobs: noLimit},
+	{snippetLines: 0, detailLines: 3, failures: noLimit, jobs: noLimit},
+	{snippetLines: 0, detailLines: 3, failures: 20, jobs: noLimit},
+	{snippetLines: 0, detailLines: 1, failures: 20, jobs: noLimit},
+	{snippetLines: 0, detailLines: 1, failures: 5, jobs: noLimit},
+	{snippetLines: 0, detailLines: 0, failures: 5, jobs: noLimit},
+	{snippetLines: 0, detailLines: 0, failures: 0, jobs: noLimit},
+	{snippetLines: 0, detailLines: 0, failures: 0, jobs: 50},
+	{snippetLines: 0, detailLines: 0, failures: 0, jobs: 10},
+}
+
+// fitComment renders the report and, while the body is over limit, renders
+// it again at each trim level in turn. A body still too long after the last
+// level is cut at a line boundary.
+func fitComment(report *FailureReport, render func(*FailureReport) string, limit int) string {
+	body := render(report)
+	if len(body) <= limit {
+		return body
+	}
+
+	for _, limits := range trimLevels {
+		if body = render(report.trimmed(limits)); len(body) <= limit {
+			return body
+		}
+	}
+	return truncateComment(body, limit)
+}
+
+// truncateComment cuts a body to at most limit bytes at a line boundary,
+// closing any open code fence and noting how much was cut
+func truncateComment(body string, limit int) string {
+	const omittedFormat = "\n\n… %d characters omitted to fit GitHub's comment size limit\n"
+	// The marker for cutting the whole body is at least as long as the real
+	// one, and no fence in the body is longer than its longest backtick run
+	closing := "\n" + strings.Repeat(" ", maxFenceIndent) + strings.Repeat("`", max(3, longestBacktickRun(body)))
+	cut := limit - len(fmt.Sprintf(omittedFormat, len(body))) - len(closing)
+	if cut < 0 {
+		cut = 0
+	}
+	for cut > 0 && !utf8.RuneStart(body[cut]) {
+		cut--
+	}
+	if i := strings.LastIndex(body[:cut], "\n"); i > 0 {
+		cut = i
+	}
+
+	kept := body[:cut]
+	if fence := openFence(kept); fence != "" {
+		kept += "\n" + fence
+	}
+	return kept + fmt.Sprintf(omittedFormat, len(body)-cut)
+}
+
+// maxFenceIndent is the most a code fence can be indented, as codeBlock
+// indents fences inside list items
+const maxFenceIndent = 8
+
+// openFence returns the fence, with its indentation, of a code block that
+// text leaves open, or "" when every block is closed. As with codeBlock,
+// a fence is a line of three or more backticks, and only a fence at least
+// as long as the opening one closes a block.
+func openFence(text string) string {
+	open := ""
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		run := len(trimmed) - len(strings.TrimLeft(trimmed, "`"))
+		if run < 3 || indent > maxFenceIndent {
+			continue
+		}
+		switch {
+		case open == "":
+			if !strings.Contains(trimmed[run:], "`") {
+				open = line[:indent+run]
+			}
+		case run >= len(strings.TrimLeft(open, " ")) && strings.TrimSpace(trimmed[run:]) == "":
+			open = ""
+		}
+	}
+	return open
+}
+
+// trimmed returns a copy of the report cut down to the given limits, with
+// the number of omitted failures and jobs recorded for rendering
+func (r *FailureReport) trimmed(limits trimLimits) *FailureReport {
+	t := *r
+	t.TestResults, t.OmittedTestResults = trimFailures(r.TestResults, limits)
+	t.Jobs, t.fingerprints = r.Jobs, r.jobFingerprints()
+	if limits.jobs != noLimit && len(t.Jobs) > limits.jobs {
+		t.OmittedJobs = len(t.Jobs) - limits.jobs
+		t.Jobs, t.fingerprints = t.Jobs[:limits.jobs], t.fingerprints[:limits.jobs]
+	}
+
+	jobs := make([]JobReport, len(t.Jobs))
+	for i, job := range t.Jobs {
+		job.Failures, job.OmittedFailures = trimFailures(job.Failures, limits)
+		if limits.snippetLines != noLimit {
+			job.Snippet = keepLastLines(job.Snippet, limits.snippetLines)
+		}
+		if limits.snippetLines == 0 {
+			job.Reproduction = ""
+		}
+		jobs[i] = job
+	}
+	t.Jobs = jobs
+	return &t
+}
+
+// trimFailures applies the failure count and detail limits to a copy of
+// failures, returning it with the number of failures dropped
+func trimFailures(failures []Failure, limits trimLimits) ([]Failure, int) {
+	omitted := 0
+	if limits.failures != noLimit && len(failures) > limits.failures {
+		omitted = len(failures) - limits.failures
+		failures = failures[:limits.failures]
+	}
+
+	trimmed := make([]Failure, len(failures))
+	for i, f := range failures {
+		if limits.detailLines != noLimit {
+			f.Message = keepFirstLines(f.Message, limits.detailLines)
+			f.Stack = keepFirstLines(f.Stack, limits.detailLines)
+		}
+		trimmed[i] = f
+	}
+	return trimmed, omitted
+}
+
+// keepFirstLines keeps the first n lines of text, noting how many were cut
+func keepFirstLines(text string, n int) string {
+	lines := strings.Split(text, "\n")
+	if text == "" || len(lines) <= n {
+		return text
+	}
+	return strings.Join(append(lines[:n:n], omittedLines(len(lines)-n)), "\n")
+}
+
+// keepLastLines keeps the last n lines of text, noting how many were cut
+func keepLastLines(text string, n int) string {
+	lines := strings.Split(text, "\n")
+	if text == "" || len(lines) <= n {
+		return text
+	}
+	return strings.Join(append([]string{omittedLines(len(lines) - n)}, lines[len(lines)-n:]...), "\n")
+}
+
+func omittedLines(n int) string {
+	if n == 1 {
+		return "… 1 line omitted"
+	}
+	return fmt.Sprintf("… %d lines omitted", n)
+}
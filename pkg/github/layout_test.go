@@ -0,0 +1,192 @@
+package github
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestKeepLines(t *testing.T) {
+	text := "one\ntwo\nthree\nfour"
+
+	tests := []struct {
+		name     string
+		got      string
+		expected string
+	}{
+		{name: "first lines", got: keepFirstLines(text, 2), expected: "one\ntwo\n… 2 lines omitted"},
+		{name: "last lines", got: keepLastLines(text, 1), expected: "… 3 lines omitted\nfour"},
+		{name: "no lines", got: keepLastLines(text, 0), expected: "… 4 lines omitted"},
+		{name: "already short", got: keepFirstLines(text, 4), expected: text},
+		{name: "empty", got: keepLastLines("", 0), expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, tt.got)
+			}
+		})
+	}
+}
+
+// largeReport builds a report with distinct content in every job
+func largeReport(jobs, failures, lines int) *FailureReport {
+	report := &FailureReport{Workflow: &WorkflowRun{ID: 1, Name: "CI", HTMLURL: "https://github.com/owner/repo/actions/runs/1"}}
+	for j := 0; j < jobs; j++ {
+		job := JobReport{Job: Job{ID: int64(j), Name: fmt.Sprintf("job-%d", j)}}
+		for f := 0; f < failures; f++ {
+			var msg []string
+			for l := 0; l < lines; l++ {
+				msg = append(msg, fmt.Sprintf("job %d failure %d detail %d %s", j, f, l, strings.Repeat("x", 80)))
+			}
+			job.Failures = append(job.Failures, Failure{Parser: "go test", TestID: fmt.Sprintf("Test%d_%d", j, f), Message: strings.Join(msg, "\n")})
+		}
+		// Letters keep the snippets of different jobs from sharing a fingerprint
+		filler := strings.Repeat(fmt.Sprintf("%c%c", 'g'+j/20, 'g'+j%20), 40)
+		var snippet []string
+		for l := 0; l < lines; l++ {
+			snippet = append(snippet, fmt.Sprintf("job %d log line %d %s", j, l, filler))
+		}
+		job.Snippet = strings.Join(snippet, "\n")
+		report.Jobs = append(report.Jobs, job)
+	}
+	return report
+}
+
+func TestBuildFailureComment_SizeLimit(t *testing.T) {
+	client := NewClient("test-token", "owner/repo")
+
+	tests := []struct {
+		name     string
+		report   *FailureReport
+		contains []string
+		missing  []string
+	}{
+		{
+			name:     "small report is untouched",
+			report:   largeReport(2, 2, 3),
+			missing:  []string{"omitted"},
+			contains: []string{"job 1 failure 1 detail 2", "job 1 log line 2"},
+		},
+		{
+			name:     "long snippets are trimmed first",
+			report:   largeReport(40, 0, 30),
+			contains: []string{"lines omitted", "job 39 log line 29"},
+			missing:  []string{"job 39 log line 0 "},
+		},
+		{
+			name:     "many failures are dropped after details",
+			report:   largeReport(10, 100, 15),
+			contains: []string{"more failure(s) omitted", "`Test9_0`"},
+		},
+		{
+			name:     "many jobs are dropped last",
+			report:   largeReport(5000, 1, 1),
+			contains: []string{"more job(s) omitted", "- job-0\n"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment := client.buildFailureComment(tt.report)
+			if len(comment) > maxCommentLength {
+				t.Fatalf("Expected at most %d characters, got %d", maxCommentLength, len(comment))
+			}
+			if !strings.Contains(comment, "@copilot") {
+				t.Error("Comment should keep the @copilot mention")
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(comment, s) {
+					t.Errorf("Expected comment to contain %q", s)
+				}
+			}
+			for _, s := range tt.missing {
+				if strings.Contains(comment, s) {
+					t.Errorf("Expected comment not to contain %q", s)
+				}
+			}
+		})
+	}
+}
+
+func TestFitComment_DoesNotModifyReport(t *testing.T) {
+	report := largeReport(10, 100, 15)
+	render := func(r *FailureReport) string { return strings.Repeat("x", len(r.Jobs[0].Failures)) }
+
+	fitComment(report, render, 10)
+
+	if len(report.Jobs[0].Failures) != 100 || report.Jobs[0].OmittedFailures != 0 {
+		t.Error("Expected the original report to be left intact")
+	}
+}
+
+func TestTruncateComment(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedTail string
+	}{
+		{
+			name:         "three backtick fence",
+			body:         "❌ header\n```\n" + strings.Repeat("é long line\n", 10000) + "```\nfooter\n",
+			expectedTail: "é long line\n```\n\n…",
+		},
+		{
+			name:         "longer fence around backticks",
+			body:         "❌ header\n````\n" + strings.Repeat("```\nlong line\n", 10000) + "````\nfooter\n",
+			expectedTail: "\n````\n\n…",
+		},
+		{
+			name:         "indented fence",
+			body:         "- item\n  ```text\n" + strings.Repeat("long line\n", 10000) + "  ```\n",
+			expectedTail: "long line\n  ```\n\n…",
+		},
+		{
+			name:         "no fence",
+			body:         strings.Repeat("long line\n", 10000),
+			expectedTail: "long line\n\n…",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateComment(tt.body, 1000)
+
+			if len(got) > 1000 {
+				t.Fatalf("Expected at most 1000 bytes, got %d", len(got))
+			}
+			if fence := openFence(got); fence != "" {
+				t.Errorf("Expected the open code fence to be closed, got %q left open in %q", fence, got[len(got)-100:])
+			}
+			if !strings.Contains(got, tt.expectedTail+" ") {
+				t.Errorf("Expected the body to end with %q and an omission marker, got %q", tt.expectedTail, got[len(got)-100:])
+			}
+		})
+	}
+}
+
+func TestOpenFence(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{name: "closed", text: "```\ncode\n```\n", expected: ""},
+		{name: "open", text: "```go\ncode\n", expected: "```"},
+		{name: "shorter run inside a longer fence", text: "````\n```\ncode\n", expected: "````"},
+		{name: "closed by a longer run", text: "```\ncode\n`````\n", expected: ""},
+		{name: "run with text does not close", text: "```\n``` not a fence\n", expected: "```"},
+		{name: "indented", text: "  ```\ncode\n", expected: "  ```"},
+		{name: "inline code", text: "use ```x``` here\n", expected: ""},
+		{name: "two backticks", text: "``\ncode\n", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := openFence(tt.text); got != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package github
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// logURL links to the first failed step of a job in the Actions log viewer,
+// at the step's first error line when it is known. stepErrorLines are those
+// of LogAnalysis, and must come from a log read from the start.
+func logURL(job Job, stepErrorLines []int) string {
+	failed := job.FailedSteps()
+	if job.HTMLURL == "" || len(failed) == 0 {
+		return ""
+	}
+
+	step := failed[0]
+	line := 1
+	if section := logSection(job.Steps, step.Number); section < len(stepErrorLines) && stepErrorLines[section] > 0 {
+		line = stepErrorLines[section]
+	}
+	return fmt.Sprintf("%s#step:%d:%d", job.HTMLURL, step.Number, line)
+}
+
+// logSection returns the section of the log holding the output of a step.
+// "Set up job" is section 0, and each later step that ran starts a section;
+// skipped steps write nothing to the log.
+func logSection(steps []Step, number int) int {
+	if number <= 1 {
+		return 0
+	}
+	section := 1
+	for _, s := range steps {
+		if s.Number > 1 && s.Number < number && s.Conclusion != "skipped" {
+			section++
+		}
+	}
+	return section
+}
+
+// linkFailures links each failure that points at a file in the repository
+// to that line at the given commit
+func (c *Client) linkFailures(failures []Failure, sha string) {
+	for i, f := range failures {
+		if f.URL != "" {
+			continue
+		}
+		if file, ok := c.repositoryPath(f); ok {
+			failures[i].URL = c.blobURL(sha, file, f.Line)
+		}
+	}
+}
+
+// repositoryPath turns the file of a failure into a path in the repository.
+// Absolute paths are kept only inside the default checkout, whose directory
+// ends in the repository name twice, e.g. /home/runner/work/repo/repo. go test
+// reports files by base name, relative to their package, so those cannot be
+// placed.
+func (c *Client) repositoryPath(f Failure) (string, bool) {
+	file := strings.ReplaceAll(f.File, `\`, "/")
+	if file == "" || f.Parser == "go test" && !strings.Contains(file, "/") {
+		return "", false
+	}
+
+	_, name, _ := strings.Cut(c.repository, "/")
+	workspace := "/" + name + "/" + name + "/"
+	if i := strings.Index(file, workspace); i >= 0 {
+		file = file[i+len(workspace):]
+	} else if path.IsAbs(file) || len(file) > 1 && file[1] == ':' {
+		return "", false
+	}
+
+	file = path.Clean(file)
+	if file == "." || file == ".." || strings.HasPrefix(file, "../") || strings.Contains(file, "node_modules/") {
+		return "", false
+	}
+	return file, true
+}
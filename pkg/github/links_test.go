@@ -0,0 +1,119 @@
+package github
+
+import "testing"
+
+func TestLogURL(t *testing.T) {
+	steps := []Step{
+		{Name: "Set up job", Number: 1, Conclusion: "success"},
+		{Name: "Run actions/checkout@v4", Number: 2, Conclusion: "success"},
+		{Name: "Lint", Number: 3, Conclusion: "skipped"},
+		{Name: "Run tests", Number: 4, Conclusion: "failure"},
+		{Name: "Complete job", Number: 5, Conclusion: "success"},
+	}
+	jobURL := "https://github.com/owner/repo/actions/runs/1/job/7"
+
+	tests := []struct {
+		name           string
+		job            Job
+		stepErrorLines []int
+		expected       string
+	}{
+		{
+			name:           "error line in the failed step",
+			job:            Job{HTMLURL: jobURL, Steps: steps},
+			stepErrorLines: []int{0, 0, 12},
+			expected:       jobURL + "#step:4:12",
+		},
+		{
+			name:     "error line unknown",
+			job:      Job{HTMLURL: jobURL, Steps: steps},
+			expected: jobURL + "#step:4:1",
+		},
+		{
+			name:           "no error line in the failed step",
+			job:            Job{HTMLURL: jobURL, Steps: steps},
+			stepErrorLines: []int{0, 3, 0},
+			expected:       jobURL + "#step:4:1",
+		},
+		{
+			name: "no failed step",
+			job:  Job{HTMLURL: jobURL, Steps: steps[:3]},
+		},
+		{
+			name: "no job page",
+			job:  Job{Steps: steps},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := logURL(tt.job, tt.stepErrorLines)
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestLinkFailures(t *testing.T) {
+	client := NewClient("test-token", "owner/repo")
+	blob := "https://github.com/owner/repo/blob/abc123/"
+
+	tests := []struct {
+		name     string
+		failure  Failure
+		expected string
+	}{
+		{
+			name:     "relative path",
+			failure:  Failure{Parser: "tsc", File: "src/app.ts", Line: 3},
+			expected: blob + "src/app.ts#L3",
+		},
+		{
+			name:     "path in the Linux workspace",
+			failure:  Failure{Parser: "stack trace", File: "/home/runner/work/repo/repo/pkg/math.go", Line: 10},
+			expected: blob + "pkg/math.go#L10",
+		},
+		{
+			name:     "path in the Windows workspace",
+			failure:  Failure{Parser: "cargo test", File: `D:\a\repo\repo\src\lib.rs`, Line: 5},
+			expected: blob + "src/lib.rs#L5",
+		},
+		{
+			name:     "path needing escapes",
+			failure:  Failure{Parser: "jest", File: "src/(app)/page test.tsx", Line: 1},
+			expected: blob + "src/%28app%29/page%20test.tsx#L1",
+		},
+		{
+			name:    "absolute path outside the workspace",
+			failure: Failure{Parser: "stack trace", File: "/usr/local/go/src/testing/testing.go", Line: 1},
+		},
+		{
+			name:    "path leaving the repository",
+			failure: Failure{Parser: "tsc", File: "../other/app.ts", Line: 1},
+		},
+		{
+			name:    "dependency",
+			failure: Failure{Parser: "jest", File: "node_modules/react/index.js", Line: 1},
+		},
+		{
+			name:    "go test base name",
+			failure: Failure{Parser: "go test", File: "math_test.go", Line: 12},
+		},
+		{
+			name:     "existing link kept",
+			failure:  Failure{Parser: "annotation", File: "a.go", Line: 1, URL: "https://example.com"},
+			expected: "https://example.com",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failures := []Failure{tt.failure}
+			client.linkFailures(failures, "abc123")
+			if failures[0].URL != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, failures[0].URL)
+			}
+		})
+	}
+}
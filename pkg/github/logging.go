@@ -0,0 +1,123 @@
+package github
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+)
+
+// Log formats accepted by NewLogger
+const (
+	LogFormatText    = "text"
+	LogFormatJSON    = "json"
+	LogFormatActions = "actions"
+)
+
+// NewLogger returns a logger writing records at or above level to w in the
+// given format: "text" or "json" for slog's handlers, or "actions" for
+// GitHub Actions, where debug, warning and error records become workflow
+// commands so that the runner hides or highlights them
+func NewLogger(w io.Writer, format string, level slog.Level) (*slog.Logger, error) {
+	opts := &slog.HandlerOptions{Level: level}
+	switch format {
+	case LogFormatText:
+		return slog.New(slog.NewTextHandler(w, opts)), nil
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, opts)), nil
+	case LogFormatActions:
+		return slog.New(NewActionsHandler(w, opts)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q", format)
+	}
+}
+
+// ActionsHandler is a slog.Handler for GitHub Actions logs. Each record is
+// written as its message followed by its attributes in logfmt. Debug
+// records are written as ::debug:: commands, shown only when step debug
+// logging is on, and warnings and errors as ::warning:: and ::error::
+// commands, which also annotate the run.
+type ActionsHandler struct {
+	out io.Writer
+	mu  *sync.Mutex
+	buf *bytes.Buffer
+	// text formats the attributes of a record into buf
+	text slog.Handler
+}
+
+// NewActionsHandler creates an ActionsHandler writing to w
+func NewActionsHandler(w io.Writer, opts *slog.HandlerOptions) *ActionsHandler {
+	if opts == nil {
+		opts = &slog.HandlerOptions{}
+	}
+	buf := &bytes.Buffer{}
+	return &ActionsHandler{
+		out: w,
+		mu:  &sync.Mutex{},
+		buf: buf,
+		text: slog.NewTextHandler(buf, &slog.HandlerOptions{
+			Level: opts.Level,
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				// The runner timestamps lines, and the level and message
+				// are written by Handle
+				if len(groups) == 0 && (a.Key == slog.TimeKey || a.Key == slog.LevelKey || a.Key == slog.MessageKey) {
+					return slog.Attr{}
+				}
+				if opts.ReplaceAttr != nil {
+					return opts.ReplaceAttr(groups, a)
+				}
+				return a
+			},
+		}),
+	}
+}
+
+// Enabled reports whether records of the level are written
+func (h *ActionsHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.text.Enabled(ctx, level)
+}
+
+// Handle writes a record as one line
+func (h *ActionsHandler) Handle(ctx context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buf.Reset()
+	if err := h.text.Handle(ctx, r); err != nil {
+		return err
+	}
+	line := r.Message
+	if attrs := strings.TrimSpace(h.buf.String()); attrs != "" {
+		line += " " + attrs
+	}
+
+	switch {
+	case r.Level >= slog.LevelError:
+		line = "::error::" + escapeCommandData(line)
+	case r.Level >= slog.LevelWarn:
+		line = "::warning::" + escapeCommandData(line)
+	case r.Level < slog.LevelInfo:
+		line = "::debug::" + escapeCommandData(line)
+	}
+	_, err := io.WriteString(h.out, line+"\n")
+	return err
+}
+
+// WithAttrs returns a handler that adds attrs to every record
+func (h *ActionsHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ActionsHandler{out: h.out, mu: h.mu, buf: h.buf, text: h.text.WithAttrs(attrs)}
+}
+
+// WithGroup returns a handler that qualifies later attributes with name
+func (h *ActionsHandler) WithGroup(name string) slog.Handler {
+	return &ActionsHandler{out: h.out, mu: h.mu, buf: h.buf, text: h.text.WithGroup(name)}
+}
+
+// escapeCommandData escapes the message of a workflow command, so that it
+// stays on one line
+func escapeCommandData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
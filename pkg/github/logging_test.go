@@ -0,0 +1,107 @@
+package github
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestActionsHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		log      func(*slog.Logger)
+		expected string
+	}{
+		{
+			name:     "info is a plain line",
+			log:      func(l *slog.Logger) { l.Info("Posted failure comment", "pr", 7) },
+			expected: "Posted failure comment repo=owner/repo pr=7\n",
+		},
+		{
+			name:     "debug is a debug command",
+			log:      func(l *slog.Logger) { l.Debug("API call", "status", 200) },
+			expected: "::debug::API call repo=owner/repo status=200\n",
+		},
+		{
+			name:     "warning is a warning command",
+			log:      func(l *slog.Logger) { l.Warn("Failed to get logs", "job_id", 12) },
+			expected: "::warning::Failed to get logs repo=owner/repo job_id=12\n",
+		},
+		{
+			name:     "error is an error command",
+			log:      func(l *slog.Logger) { l.Error("Failed", "error", "boom") },
+			expected: "::error::Failed repo=owner/repo error=boom\n",
+		},
+		{
+			name:     "command data is escaped",
+			log:      func(l *slog.Logger) { l.Warn("100% failed\nagain") },
+			expected: "::warning::100%25 failed%0Aagain repo=owner/repo\n",
+		},
+		{
+			name:     "values cannot break the line",
+			log:      func(l *slog.Logger) { l.Info("Processing pull request event", "title", "fix\n::error::x") },
+			expected: "Processing pull request event repo=owner/repo title=\"fix\\n::error::x\"\n",
+		},
+		{
+			name:     "groups qualify keys",
+			log:      func(l *slog.Logger) { l.WithGroup("job").Info("Analyzed job", "id", 3) },
+			expected: "Analyzed job repo=owner/repo job.id=3\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			logger := slog.New(NewActionsHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})).With("repo", "owner/repo")
+			tt.log(logger)
+			if buf.String() != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, buf.String())
+			}
+		})
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := NewLogger(&buf, LogFormatJSON, slog.LevelInfo)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	logger.Debug("hidden")
+	logger.Info("shown", "pr", 7)
+
+	var record map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatalf("Expected one JSON record, got %q: %v", buf.String(), err)
+	}
+	if record["msg"] != "shown" || record["pr"] != float64(7) {
+		t.Errorf("Expected the info record with its fields, got %v", record)
+	}
+
+	if _, err := NewLogger(&buf, "xml", slog.LevelInfo); err == nil {
+		t.Error("Expected an error for an unknown format")
+	}
+}
+
+func TestClientLogsStructuredFields(t *testing.T) {
+	var buf bytes.Buffer
+	config := DefaultConfig()
+	config.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	client := NewClientWithConfig("test-token", "owner/repo", config)
+
+	event := &PullRequestEvent{PullRequest: PullRequest{Number: 7, User: User{Login: "octocat"}}}
+	if err := client.HandlePullRequest(event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var last map[string]interface{}
+	if err := json.Unmarshal([]byte(lines[len(lines)-1]), &last); err != nil {
+		t.Fatalf("Expected JSON records, got %q: %v", buf.String(), err)
+	}
+	if last["msg"] != "PR is not from Copilot, skipping" || last["repo"] != "owner/repo" || last["pr"] != float64(7) {
+		t.Errorf("Expected the skip record with repo and pr fields, got %v", last)
+	}
+}
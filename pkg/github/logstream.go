@@ -0,0 +1,181 @@
+package github
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// maxLineBytes bounds how much of a single log line is kept; the rest of an
+// over-long line is discarded rather than buffered
+const maxLineBytes = 64 * 1024
+
+// forEachLine calls fn for every line read from r without holding more than
+// one line in memory. Lines longer than maxLineBytes are truncated.
+func forEachLine(r io.Reader, fn func(line string)) error {
+	reader := bufio.NewReaderSize(r, maxLineBytes)
+	for {
+		chunk, err := reader.ReadSlice('\n')
+		if err == bufio.ErrBufferFull {
+			line := string(chunk)
+			// Skip the remainder of the over-long line
+			for err == bufio.ErrBufferFull {
+				_, err = reader.ReadSlice('\n')
+			}
+			fn(line)
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if len(chunk) > 0 {
+			fn(string(bytes.TrimSuffix(chunk, []byte("\n"))))
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// lineRing keeps the last n lines added to it
+type lineRing struct {
+	lines []string
+	next  int
+	full  bool
+}
+
+func newLineRing(n int) *lineRing {
+	if n < 0 {
+		n = 0
+	}
+	return &lineRing{lines: make([]string, n)}
+}
+
+// Add appends a line, evicting the oldest one when the ring is full
+func (r *lineRing) Add(line string) {
+	if len(r.lines) == 0 {
+		return
+	}
+	r.lines[r.next] = line
+	r.next = (r.next + 1) % len(r.lines)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Len returns the number of lines held
+func (r *lineRing) Len() int {
+	if r.full {
+		return len(r.lines)
+	}
+	return r.next
+}
+
+// Lines returns the held lines, oldest first
+func (r *lineRing) Lines() []string {
+	if !r.full {
+		return append([]string{}, r.lines[:r.next]...)
+	}
+	return append(append([]string{}, r.lines[r.next:]...), r.lines[:r.next]...)
+}
+
+// snippetCollector builds the heuristic error snippet from a stream of
+// lines: the last n lines mentioning an error, or else the last n lines
+type snippetCollector struct {
+	keywords keywordMatcher
+	errors   *lineRing
+	tail     *lineRing
+}
+
+func newSnippetCollector(n int, keywords keywordMatcher) *snippetCollector {
+	return &snippetCollector{keywords: keywords, errors: newLineRing(n), tail: newLineRing(n)}
+}
+
+func (s *snippetCollector) Add(line string) {
+	if s.keywords.Match(line) {
+		s.errors.Add(line)
+	}
+	s.tail.Add(line)
+}
+
+func (s *snippetCollector) String() string {
+	if s.errors.Len() > 0 {
+		return strings.Join(s.errors.Lines(), "\n")
+	}
+	return strings.Join(s.tail.Lines(), "\n")
+}
+
+// stepLocator splits a job log into the sections the Actions log viewer
+// shows as steps, and records where the first error line of each falls.
+// Section 0 is "Set up job"; each "##[group]Run" header starts the next one.
+type stepLocator struct {
+	keywords   keywordMatcher
+	line       int  // line number within the current section
+	inHeader   bool // inside the group that echoes the step's command
+	errorLines []int
+}
+
+func newStepLocator(keywords keywordMatcher) *stepLocator {
+	return &stepLocator{keywords: keywords, errorLines: []int{0}}
+}
+
+func (l *stepLocator) Add(line string) {
+	if strings.HasPrefix(line, "##[group]Run ") {
+		l.errorLines = append(l.errorLines, 0)
+		l.line, l.inHeader = 1, true
+		return
+	}
+	l.line++
+	if l.inHeader {
+		l.inHeader = !strings.HasPrefix(line, "##[endgroup]")
+		return
+	}
+	current := len(l.errorLines) - 1
+	if l.errorLines[current] == 0 && l.keywords.Match(line) {
+		l.errorLines[current] = l.line
+	}
+}
+
+// ErrorLines returns, for each section, the line within it of its first
+// error line, or 0 when it has none
+func (l *stepLocator) ErrorLines() []int {
+	return l.errorLines
+}
+
+// logTail is a job log read from the middle, whose line numbers do not match
+// the log viewer
+type logTail struct {
+	io.Reader
+	io.Closer
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// dropFirstLine discards everything up to and including the first newline of
+// a log fetched from the middle, where the first line is likely partial
+func dropFirstLine(body io.ReadCloser) io.ReadCloser {
+	reader := bufio.NewReader(body)
+	for {
+		if _, err := reader.ReadSlice('\n'); err != bufio.ErrBufferFull {
+			break
+		}
+	}
+	return logTail{reader, body}
+}
@@ -0,0 +1,225 @@
+package github
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// problemMatcherFile is the Actions problem matcher JSON format, as used with
+// the ::add-matcher:: workflow command
+type problemMatcherFile struct {
+	ProblemMatcher []problemMatcherDef `json:"problemMatcher"`
+}
+
+type problemMatcherDef struct {
+	Owner    string              `json:"owner"`
+	Severity string              `json:"severity"`
+	Pattern  []problemPatternDef `json:"pattern"`
+}
+
+type problemPatternDef struct {
+	Regexp   string `json:"regexp"`
+	File     int    `json:"file"`
+	FromPath int    `json:"fromPath"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Severity int    `json:"severity"`
+	Code     int    `json:"code"`
+	Message  int    `json:"message"`
+	Loop     bool   `json:"loop"`
+}
+
+// LoadProblemMatchers reads Actions problem matcher files and returns an
+// extractor per matcher. Paths may be glob patterns.
+func LoadProblemMatchers(paths []string) ([]Extractor, error) {
+	var extractors []Extractor
+	for _, pattern := range paths {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid problem matcher path %q: %w", pattern, err)
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("no problem matcher files match %q", pattern)
+		}
+		for _, file := range files {
+			data, err := os.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			matchers, err := ParseProblemMatchers(data)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", file, err)
+			}
+			extractors = append(extractors, matchers...)
+		}
+	}
+	return extractors, nil
+}
+
+// ParseProblemMatchers parses the contents of a problem matcher file
+func ParseProblemMatchers(data []byte) ([]Extractor, error) {
+	var file problemMatcherFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	if len(file.ProblemMatcher) == 0 {
+		return nil, fmt.Errorf("no problemMatcher entries")
+	}
+
+	var extractors []Extractor
+	for _, def := range file.ProblemMatcher {
+		m, err := compileProblemMatcher(def)
+		if err != nil {
+			return nil, fmt.Errorf("matcher %q: %w", def.Owner, err)
+		}
+		extractors = append(extractors, m)
+	}
+	return extractors, nil
+}
+
+// problemMatcher is a compiled problem matcher
+type problemMatcher struct {
+	owner    string
+	severity string
+	patterns []problemPattern
+}
+
+type problemPattern struct {
+	problemPatternDef
+	re *regexp.Regexp
+}
+
+func compileProblemMatcher(def problemMatcherDef) (*problemMatcher, error) {
+	if def.Owner == "" {
+		return nil, fmt.Errorf("owner is required")
+	}
+	if len(def.Pattern) == 0 {
+		return nil, fmt.Errorf("at least one pattern is required")
+	}
+
+	m := &problemMatcher{owner: def.Owner, severity: strings.ToLower(def.Severity)}
+	for i, p := range def.Pattern {
+		if p.Loop && i != len(def.Pattern)-1 {
+			return nil, fmt.Errorf("only the last pattern may loop")
+		}
+		re, err := regexp.Compile(p.Regexp)
+		if err != nil {
+			return nil, fmt.Errorf("pattern %d: %w", i, err)
+		}
+		m.patterns = append(m.patterns, problemPattern{problemPatternDef: p, re: re})
+	}
+
+	last := def.Pattern[len(def.Pattern)-1]
+	if last.Message == 0 {
+		return nil, fmt.Errorf("the last pattern must capture a message")
+	}
+	return m, nil
+}
+
+func (m *problemMatcher) Name() string      { return "matcher:" + m.owner }
+func (m *problemMatcher) NewParser() Parser { return &problemMatcherParser{matcher: m} }
+
+// problemMatcherParser runs a matcher's patterns over the log the way the
+// runner does: patterns must match consecutive lines, and a looping last
+// pattern keeps producing problems until a line fails to match it
+type problemMatcherParser struct {
+	matcher  *problemMatcher
+	failures []Failure
+	index    int
+	partial  problemFields
+	looping  bool
+}
+
+// problemFields holds the values captured so far for one problem
+type problemFields struct {
+	file, fromPath, line, column, severity, code, message string
+}
+
+func (p *problemMatcherParser) Feed(line string) {
+	if p.match(line) {
+		return
+	}
+	// A broken sequence restarts matching at the first pattern
+	if p.index > 0 || p.looping {
+		p.reset()
+		p.match(line)
+	}
+}
+
+// match tries the current pattern against the line and advances the state
+func (p *problemMatcherParser) match(line string) bool {
+	pattern := p.matcher.patterns[p.index]
+	m := pattern.re.FindStringSubmatch(line)
+	if m == nil {
+		return false
+	}
+
+	fields := p.partial
+	capture := func(group int, dst *string) {
+		if group > 0 && group < len(m) && m[group] != "" {
+			*dst = m[group]
+		}
+	}
+	capture(pattern.File, &fields.file)
+	capture(pattern.FromPath, &fields.fromPath)
+	capture(pattern.Line, &fields.line)
+	capture(pattern.Column, &fields.column)
+	capture(pattern.Severity, &fields.severity)
+	capture(pattern.Code, &fields.code)
+	capture(pattern.Message, &fields.message)
+
+	if p.index < len(p.matcher.patterns)-1 {
+		p.partial = fields
+		p.index++
+		return true
+	}
+
+	p.emit(fields)
+	if pattern.Loop {
+		p.looping = true
+	} else {
+		p.reset()
+	}
+	return true
+}
+
+func (p *problemMatcherParser) emit(fields problemFields) {
+	severity := strings.ToLower(fields.severity)
+	if severity == "" {
+		severity = p.matcher.severity
+	}
+	if severity != "" && severity != "error" || len(p.failures) >= maxFailures {
+		return
+	}
+
+	file := fields.file
+	if fields.fromPath != "" && file != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(filepath.Dir(fields.fromPath), file)
+	}
+	p.failures = append(p.failures, Failure{
+		Parser:  p.Name(),
+		TestID:  fields.code,
+		File:    file,
+		Line:    atoi(fields.line),
+		Column:  atoi(fields.column),
+		Message: fields.message,
+	})
+}
+
+func (p *problemMatcherParser) reset() {
+	p.index = 0
+	p.partial = problemFields{}
+	p.looping = false
+}
+
+func (p *problemMatcherParser) Name() string {
+	return p.matcher.Name()
+}
+
+func (p *problemMatcherParser) Failures() []Failure {
+	return p.failures
+}
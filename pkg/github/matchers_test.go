@@ -0,0 +1,132 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestProblemMatcher_SingleLine(t *testing.T) {
+	matchers, err := ParseProblemMatchers([]byte(`{
+		"problemMatcher": [{
+			"owner": "eslint-compact",
+			"pattern": [{
+				"regexp": "^(.+):\\sline\\s(\\d+),\\scol\\s(\\d+),\\s(Error|Warning)\\s-\\s(.+)\\s\\((.+)\\)$",
+				"file": 1, "line": 2, "column": 3, "severity": 4, "message": 5, "code": 6
+			}]
+		}]
+	}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	logs := "src/a.js: line 1, col 10, Error - 'foo' is defined but never used (no-unused-vars)\n" +
+		"src/a.js: line 2, col 1, Warning - Unexpected console statement (no-console)"
+	failures := runParser(matchers[0], logs)
+
+	expected := []Failure{
+		{Parser: "matcher:eslint-compact", TestID: "no-unused-vars", File: "src/a.js", Line: 1, Column: 10, Message: "'foo' is defined but never used"},
+	}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Errorf("Expected:\n%+v\n\nGot:\n%+v", expected, failures)
+	}
+}
+
+func TestProblemMatcher_MultiLineLoop(t *testing.T) {
+	matchers, err := ParseProblemMatchers([]byte(`{
+		"problemMatcher": [{
+			"owner": "eslint-stylish",
+			"pattern": [
+				{"regexp": "^([^\\s].*)$", "file": 1},
+				{"regexp": "^\\s+(\\d+):(\\d+)\\s+(error|warning|info)\\s+(.*)\\s\\s+(.*)$", "line": 1, "column": 2, "severity": 3, "message": 4, "code": 5, "loop": true}
+			]
+		}]
+	}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	logs := strings.Join([]string{
+		"src/a.js",
+		"  1:10  error  'foo' is defined but never used  no-unused-vars",
+		"  3:1   error  Missing semicolon  semi",
+		"",
+		"src/b.js",
+		"  7:2   warning  Unexpected console statement  no-console",
+		"  9:4   error  'bar' is not defined  no-undef",
+	}, "\n")
+	failures := runParser(matchers[0], logs)
+
+	expected := []Failure{
+		{Parser: "matcher:eslint-stylish", TestID: "no-unused-vars", File: "src/a.js", Line: 1, Column: 10, Message: "'foo' is defined but never used"},
+		{Parser: "matcher:eslint-stylish", TestID: "semi", File: "src/a.js", Line: 3, Column: 1, Message: "Missing semicolon"},
+		{Parser: "matcher:eslint-stylish", TestID: "no-undef", File: "src/b.js", Line: 9, Column: 4, Message: "'bar' is not defined"},
+	}
+	if !reflect.DeepEqual(failures, expected) {
+		t.Errorf("Expected:\n%+v\n\nGot:\n%+v", expected, failures)
+	}
+}
+
+func TestProblemMatcher_DefaultSeverity(t *testing.T) {
+	matchers, err := ParseProblemMatchers([]byte(`{
+		"problemMatcher": [{
+			"owner": "lint-warnings",
+			"severity": "warning",
+			"pattern": [{"regexp": "^(.+):(\\d+): (.+)$", "file": 1, "line": 2, "message": 3}]
+		}]
+	}`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if failures := runParser(matchers[0], "a.py:1: unused import"); len(failures) != 0 {
+		t.Errorf("Expected warning-level problems to be ignored, got %+v", failures)
+	}
+}
+
+func TestParseProblemMatchers_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "not json", data: "nope"},
+		{name: "no matchers", data: `{"problemMatcher": []}`},
+		{name: "missing owner", data: `{"problemMatcher": [{"pattern": [{"regexp": "x", "message": 1}]}]}`},
+		{name: "bad regexp", data: `{"problemMatcher": [{"owner": "x", "pattern": [{"regexp": "(", "message": 1}]}]}`},
+		{name: "no message", data: `{"problemMatcher": [{"owner": "x", "pattern": [{"regexp": "(.*)", "file": 1}]}]}`},
+		{name: "loop not last", data: `{"problemMatcher": [{"owner": "x", "pattern": [{"regexp": "a", "loop": true}, {"regexp": "(b)", "message": 1}]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ParseProblemMatchers([]byte(tt.data)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+}
+
+func TestLoadProblemMatchers(t *testing.T) {
+	dir := t.TempDir()
+	matcher := `{"problemMatcher": [{"owner": "%s", "pattern": [{"regexp": "^(.+)$", "message": 1}]}]}`
+	for _, name := range []string{"one", "two"} {
+		data := strings.Replace(matcher, "%s", name, 1)
+		if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	extractors, err := LoadProblemMatchers([]string{filepath.Join(dir, "*.json")})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(extractors) != 2 || extractors[0].Name() != "matcher:one" || extractors[1].Name() != "matcher:two" {
+		t.Errorf("Expected matchers one and two, got %v", extractors)
+	}
+
+	if _, err := LoadProblemMatchers([]string{filepath.Join(dir, "missing.json")}); err == nil {
+		t.Error("Expected an error for a path that matches no files")
+	}
+}
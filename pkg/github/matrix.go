@@ -0,0 +1,119 @@
+package github
+
+import (
+	"fmt"
+	"hash/fnv"
+	"io"
+	"strings"
+)
+
+// FailureGroup is a set of failed jobs whose logs show the same failures,
+// typically legs of a matrix that broke for one reason
+type FailureGroup struct {
+	Jobs []JobReport
+	// Divergent is set when the group's jobs are matrix legs that fail
+	// differently from most other legs of the same matrix
+	Divergent bool
+}
+
+// Names returns the names of the jobs in the group
+func (g FailureGroup) Names() []string {
+	names := make([]string, len(g.Jobs))
+	for i, job := range g.Jobs {
+		names[i] = job.Job.Name
+	}
+	return names
+}
+
+// groupJobReports groups the jobs that have log failures or a snippet by
+// their fingerprints, in order of first appearance
+func groupJobReports(jobs []JobReport, fingerprints []uint64) []FailureGroup {
+	var groups []FailureGroup
+	index := make(map[uint64]int)
+	for i, job := range jobs {
+		if !job.hasLogs() {
+			continue
+		}
+		key := fingerprints[i]
+		if i, ok := index[key]; ok {
+			groups[i].Jobs = append(groups[i].Jobs, job)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, FailureGroup{Jobs: []JobReport{job}})
+	}
+
+	markDivergentGroups(groups)
+	return groups
+}
+
+// markDivergentGroups flags the groups holding matrix legs that fail
+// differently from the largest group of legs of the same matrix
+func markDivergentGroups(groups []FailureGroup) {
+	// Count the legs of each matrix in each group
+	legs := make(map[string]map[int]int)
+	for i, g := range groups {
+		for _, job := range g.Jobs {
+			name := matrixName(job.Job.Name)
+			if name == "" {
+				continue
+			}
+			if legs[name] == nil {
+				legs[name] = make(map[int]int)
+			}
+			legs[name][i]++
+		}
+	}
+
+	for _, counts := range legs {
+		if len(counts) < 2 {
+			continue
+		}
+		// The earliest of the largest groups is the common failure
+		common := -1
+		for i := range groups {
+			if n, ok := counts[i]; ok && (common < 0 || n > counts[common]) {
+				common = i
+			}
+		}
+		for i := range counts {
+			if i != common {
+				groups[i].Divergent = true
+			}
+		}
+	}
+}
+
+// matrixName returns the job name without its matrix values, e.g. "test" for
+// "test (1.22, ubuntu-latest)", or "" when the job is not a matrix leg
+func matrixName(jobName string) string {
+	if !strings.HasSuffix(jobName, ")") {
+		return ""
+	}
+	i := strings.LastIndex(jobName, " (")
+	if i <= 0 {
+		return ""
+	}
+	return jobName[:i]
+}
+
+// jobFingerprint hashes what a job report shows with run-specific details
+// such as durations, ids and links left out, so that matrix legs failing the
+// same way get the same fingerprint
+func jobFingerprint(job JobReport) uint64 {
+	h := fnv.New64a()
+	for _, f := range job.Failures {
+		fmt.Fprintf(h, "%s\x00%s\x00%s\x00%d\x00", f.Parser, f.TestID, f.File, f.Line)
+		hashLines(h, f.Message)
+		hashLines(h, f.Stack)
+	}
+	hashLines(h, job.Snippet)
+	return h.Sum64()
+}
+
+// hashLines writes the fingerprint of every line of text to w
+func hashLines(w io.Writer, text string) {
+	for _, line := range strings.Split(text, "\n") {
+		fmt.Fprintf(w, "%s\x00", fingerprintLine(line))
+	}
+}
@@ -0,0 +1,99 @@
+package github
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMatrixName(t *testing.T) {
+	tests := []struct {
+		jobName  string
+		expected string
+	}{
+		{jobName: "test (1.22, ubuntu-latest)", expected: "test"},
+		{jobName: "build / test (linux)", expected: "build / test"},
+		{jobName: "lint", expected: ""},
+		{jobName: "(weird)", expected: ""},
+	}
+
+	for _, tt := range tests {
+		if got := matrixName(tt.jobName); got != tt.expected {
+			t.Errorf("matrixName(%q): expected %q, got %q", tt.jobName, tt.expected, got)
+		}
+	}
+}
+
+func TestGroupJobReports(t *testing.T) {
+	timeout := func(d string) string {
+		return "panic: test timed out after " + d + "\nrunning tests:\n\tTestServer (" + d + ")"
+	}
+	jobs := []JobReport{
+		{
+			Job: Job{Name: "test (1.21, ubuntu)"},
+			Failures: []Failure{
+				{Parser: "go test", TestID: "TestAdd", File: "math_test.go", Line: 12, Message: "got 6, want 5", Duration: 10},
+			},
+		},
+		{Job: Job{Name: "lint"}},
+		{
+			Job: Job{Name: "test (1.22, ubuntu)"},
+			Failures: []Failure{
+				{Parser: "go test", TestID: "TestAdd", File: "math_test.go", Line: 12, Message: "got 6, want 5", Duration: 30},
+			},
+		},
+		{Job: Job{Name: "test (1.22, macos)"}, Snippet: timeout("10m0s")},
+		{
+			Job: Job{Name: "test (1.23, ubuntu)"},
+			Failures: []Failure{
+				{Parser: "go test", TestID: "TestAdd", File: "math_test.go", Line: 12, Message: "got 6, want 5"},
+			},
+		},
+		{Job: Job{Name: "build (arm64)"}, Snippet: timeout("5m0s")},
+	}
+
+	report := &FailureReport{Jobs: jobs}
+	groups := groupJobReports(jobs, report.jobFingerprints())
+
+	var names [][]string
+	var divergent []bool
+	for _, g := range groups {
+		names = append(names, g.Names())
+		divergent = append(divergent, g.Divergent)
+	}
+	expectedNames := [][]string{
+		{"test (1.21, ubuntu)", "test (1.22, ubuntu)", "test (1.23, ubuntu)"},
+		{"test (1.22, macos)", "build (arm64)"},
+	}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Errorf("Expected groups %v, got %v", expectedNames, names)
+	}
+	if !reflect.DeepEqual(divergent, []bool{false, true}) {
+		t.Errorf("Expected only the macOS group to be divergent, got %v", divergent)
+	}
+}
+
+func TestBuildFailureComment_MatrixGroups(t *testing.T) {
+	client := NewClient("test-token", "owner/repo")
+	report := &FailureReport{
+		Workflow: &WorkflowRun{ID: 123, Name: "CI"},
+		Jobs: []JobReport{
+			{Job: Job{Name: "test (1.21)"}, Snippet: "Error: build failed after 12s"},
+			{Job: Job{Name: "test (1.22)"}, Snippet: "Error: build failed after 15s"},
+			{Job: Job{Name: "test (1.23)"}, Snippet: "Error: build failed after 9s"},
+			{Job: Job{Name: "test (tip)"}, Snippet: "Error: unsupported toolchain"},
+		},
+	}
+
+	comment := client.buildFailureComment(report)
+
+	if strings.Count(comment, "Error: build failed") != 1 {
+		t.Errorf("Expected the shared snippet once, got:\n%s", comment)
+	}
+	if !strings.Contains(comment, "**Jobs: test (1.21), test (1.22), test (1.23)**\n") {
+		t.Errorf("Expected the affected legs to be listed, got:\n%s", comment)
+	}
+	if !strings.Contains(comment, "**Job: test (tip)** ⚠️") {
+		t.Errorf("Expected the divergent leg to be highlighted, got:\n%s", comment)
+	}
+}
@@ -0,0 +1,189 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maxBaselineLines bounds how many distinct lines a baseline remembers
+const maxBaselineLines = 1 << 20
+
+// LogBaseline is the set of line fingerprint hashes seen in a passing job log
+type LogBaseline map[uint64]struct{}
+
+var (
+	volatileUUIDRegex   = regexp.MustCompile(`(?i)\b[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}\b`)
+	volatileHexRegex    = regexp.MustCompile(`(?i)\b[0-9a-f]{7,}\b`)
+	volatileNumberRegex = regexp.MustCompile(`\d+`)
+	whitespaceRegex     = regexp.MustCompile(`\s+`)
+)
+
+// fingerprintLine reduces a normalized log line to a form that is stable
+// across runs by replacing ids, hashes, numbers and durations
+func fingerprintLine(line string) string {
+	line = volatileUUIDRegex.ReplaceAllString(line, "<uuid>")
+	line = volatileHexRegex.ReplaceAllString(line, "<hex>")
+	line = volatileNumberRegex.ReplaceAllString(line, "0")
+	line = whitespaceRegex.ReplaceAllString(line, " ")
+	return strings.TrimSpace(line)
+}
+
+// hashLine hashes the fingerprint of a normalized log line
+func hashLine(line string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(fingerprintLine(line)))
+	return h.Sum64()
+}
+
+// NewLogBaseline fingerprints every line of a passing job log
+func NewLogBaseline(logs io.Reader) (LogBaseline, error) {
+	baseline := make(LogBaseline)
+	err := forEachLine(logs, func(line string) {
+		if len(baseline) < maxBaselineLines {
+			baseline[hashLine(normalizeLogLine(line))] = struct{}{}
+		}
+	})
+	return baseline, err
+}
+
+// Contains reports whether a normalized line was seen in the passing log
+func (b LogBaseline) Contains(line string) bool {
+	_, ok := b[hashLine(line)]
+	return ok
+}
+
+// isRunnerNoise reports whether a line is runner bookkeeping that says
+// nothing about the failure
+func isRunnerNoise(line string) bool {
+	return line == "" ||
+		strings.HasPrefix(line, "##[group]") ||
+		strings.HasPrefix(line, "##[endgroup]") ||
+		strings.HasPrefix(line, "shell: ")
+}
+
+// noveltyCollector keeps the lines of a failing log that are not in a
+// baseline, preferring those that mention an error and those nearest the end
+type noveltyCollector struct {
+	baseline LogBaseline
+	keywords keywordMatcher
+	index    int
+	errors   *indexedRing
+	others   *indexedRing
+	n        int
+}
+
+func newNoveltyCollector(baseline LogBaseline, n int, keywords keywordMatcher) *noveltyCollector {
+	return &noveltyCollector{baseline: baseline, keywords: keywords, errors: newIndexedRing(n), others: newIndexedRing(n), n: n}
+}
+
+func (c *noveltyCollector) Add(line string) {
+	c.index++
+	if isRunnerNoise(strings.TrimSpace(line)) || c.baseline.Contains(line) {
+		return
+	}
+	if c.keywords.Match(line) {
+		c.errors.Add(c.index, line)
+	} else {
+		c.others.Add(c.index, line)
+	}
+}
+
+// String returns the selected novel lines in log order
+func (c *noveltyCollector) String() string {
+	selected := c.errors.Entries()
+	others := c.others.Entries()
+	if missing := c.n - len(selected); missing > 0 {
+		if missing < len(others) {
+			others = others[len(others)-missing:]
+		}
+		selected = mergeByIndex(selected, others)
+	}
+
+	lines := make([]string, len(selected))
+	for i, e := range selected {
+		lines[i] = e.line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// indexedLine is a log line with its position in the log
+type indexedLine struct {
+	index int
+	line  string
+}
+
+// indexedRing keeps the last n indexed lines added to it
+type indexedRing struct {
+	entries []indexedLine
+	n       int
+}
+
+func newIndexedRing(n int) *indexedRing {
+	return &indexedRing{n: n}
+}
+
+func (r *indexedRing) Add(index int, line string) {
+	if r.n <= 0 {
+		return
+	}
+	if len(r.entries) == r.n {
+		copy(r.entries, r.entries[1:])
+		r.entries = r.entries[:r.n-1]
+	}
+	r.entries = append(r.entries, indexedLine{index: index, line: line})
+}
+
+func (r *indexedRing) Entries() []indexedLine {
+	return append([]indexedLine{}, r.entries...)
+}
+
+// mergeByIndex merges two index-ordered line lists
+func mergeByIndex(a, b []indexedLine) []indexedLine {
+	merged := make([]indexedLine, 0, len(a)+len(b))
+	for len(a) > 0 && len(b) > 0 {
+		if a[0].index < b[0].index {
+			merged, a = append(merged, a[0]), a[1:]
+		} else {
+			merged, b = append(merged, b[0]), b[1:]
+		}
+	}
+	return append(append(merged, a...), b...)
+}
+
+// getBaselineJobs returns the jobs of the most recent successful run of the
+// same workflow on the given branch, keyed by job name
+func (c *Client) getBaselineJobs(ctx context.Context, workflow *WorkflowRun, branch string) (map[string]Job, error) {
+	if workflow.WorkflowID == 0 || branch == "" {
+		return nil, nil
+	}
+
+	runsURL := fmt.Sprintf("%s/repos/%s/actions/workflows/%d/runs?branch=%s&status=success&per_page=1",
+		c.baseURL, c.repository, workflow.WorkflowID, url.QueryEscape(branch))
+	var runsResp WorkflowRunsResponse
+	if err := c.getJSON(ctx, runsURL, &runsResp); err != nil {
+		return nil, err
+	}
+	if len(runsResp.WorkflowRuns) == 0 {
+		return nil, nil
+	}
+
+	run := runsResp.WorkflowRuns[0]
+	c.logger.Debug("Found the last successful run", "branch", branch, "baseline_run_id", run.ID)
+	jobs, err := c.getWorkflowJobs(ctx, run.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	byName := make(map[string]Job)
+	for _, job := range jobs {
+		if job.Conclusion == "success" {
+			byName[job.Name] = job
+		}
+	}
+	return byName, nil
+}
@@ -0,0 +1,138 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFingerprintLine(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+	}{
+		{name: "durations", a: "ok  	example.com/app	0.003s", b: "ok  	example.com/app	1.250s"},
+		{name: "commit hashes", a: "HEAD is now at 3f2a9c1d Fix", b: "HEAD is now at 8e7b6a5f Fix"},
+		{name: "uuids", a: "request 123e4567-e89b-12d3-a456-426614174000 done", b: "request 9f1c2d3e-aaaa-bbbb-cccc-0123456789ab done"},
+		{name: "whitespace", a: "Tests:   1 passed", b: "Tests: 2 passed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fingerprintLine(tt.a) != fingerprintLine(tt.b) {
+				t.Errorf("Expected %q and %q to share a fingerprint, got %q and %q",
+					tt.a, tt.b, fingerprintLine(tt.a), fingerprintLine(tt.b))
+			}
+		})
+	}
+}
+
+// baselineOf fingerprints a passing log held in a string
+func baselineOf(t *testing.T, logs string) LogBaseline {
+	t.Helper()
+	baseline, err := NewLogBaseline(strings.NewReader(logs))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	return baseline
+}
+
+func TestAnalyze_NovelSnippet(t *testing.T) {
+	passing := "2024-05-01T10:00:00Z ##[group]Run go test ./...\n" +
+		"2024-05-01T10:00:01Z go: downloading example.com/dep v1.2.3\n" +
+		"2024-05-01T10:00:02Z warning: failed to load cache, continuing\n" +
+		"2024-05-01T10:00:03Z ok  	example.com/app	0.010s\n"
+	failing := "2024-05-02T10:00:00Z ##[group]Run go test ./...\n" +
+		"2024-05-02T10:00:01Z go: downloading example.com/dep v1.2.3\n" +
+		"2024-05-02T10:00:02Z warning: failed to load cache, continuing\n" +
+		"2024-05-02T10:00:03Z connecting to database\n" +
+		"2024-05-02T10:00:04Z panic: runtime error: invalid memory address\n" +
+		"2024-05-02T10:00:05Z FAIL	example.com/app	0.020s\n"
+
+	analysis := analyze(t, NewRegistry(), failing, AnalyzeOptions{Lines: 2, Baseline: baselineOf(t, passing)})
+
+	expected := "panic: runtime error: invalid memory address\nFAIL	example.com/app	0.020s"
+	if analysis.Snippet != expected {
+		t.Errorf("Expected:\n%s\n\nGot:\n%s", expected, analysis.Snippet)
+	}
+}
+
+func TestAnalyze_NovelSnippetFallsBack(t *testing.T) {
+	logs := "2024-05-01T10:00:00Z Error: flaky\n"
+
+	analysis := analyze(t, NewRegistry(), logs, AnalyzeOptions{Lines: 20, Baseline: baselineOf(t, logs)})
+
+	if analysis.Snippet != "Error: flaky" {
+		t.Errorf("Expected the keyword snippet when every line is in the baseline, got %q", analysis.Snippet)
+	}
+}
+
+func TestGetBaselineJobs(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/actions/workflows/7/runs":
+			if r.URL.Query().Get("branch") != "main" || r.URL.Query().Get("status") != "success" {
+				t.Errorf("Unexpected query: %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, `{"total_count":1,"workflow_runs":[{"id":99}]}`)
+		case "/repos/owner/repo/actions/runs/99/jobs":
+			fmt.Fprint(w, `{"total_count":2,"jobs":[{"id":1,"name":"test","conclusion":"success"},{"id":2,"name":"lint","conclusion":"skipped"}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-token", "owner/repo")
+	client.baseURL = srv.URL
+
+	jobs, err := client.getBaselineJobs(context.Background(), &WorkflowRun{ID: 100, WorkflowID: 7}, "main")
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(jobs) != 1 || jobs["test"].ID != 1 {
+		t.Errorf("Expected only the successful 'test' job, got %+v", jobs)
+	}
+}
+
+func TestGetLogBaseline_IgnoresTail(t *testing.T) {
+	var logs strings.Builder
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&logs, "step %c done\n", 'A'+rune(i%26))
+	}
+	content := logs.String()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/owner/repo/actions/jobs/1/logs":
+			http.Redirect(w, r, srv.URL+"/storage/1.txt", http.StatusFound)
+		case "/storage/1.txt":
+			if r.Header.Get("Range") != "" {
+				t.Errorf("Expected the baseline log to be fetched whole, got range %q", r.Header.Get("Range"))
+			}
+			http.ServeContent(w, r, "1.txt", time.Time{}, strings.NewReader(content))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	config := DefaultConfig()
+	config.LogTailBytes = 30
+	client := NewClientWithConfig("test-token", "owner/repo", config)
+	client.baseURL = srv.URL
+
+	baseline, err := client.getLogBaseline(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(baseline) != 26 {
+		t.Errorf("Expected every distinct line of the log in the baseline, got %d", len(baseline))
+	}
+}
@@ -0,0 +1,584 @@
+package github
+
+import (
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// maxMessageLines bounds how many lines of output are kept per failure
+	maxMessageLines = 15
+	// maxFailures bounds how many failures a single parser records
+	maxFailures = 100
+)
+
+// appendLine appends a line to a failure message, keeping it bounded
+func appendLine(message, line string) string {
+	if strings.Count(message, "\n") >= maxMessageLines-1 {
+		return message
+	}
+	if message == "" {
+		return line
+	}
+	return message + "\n" + line
+}
+
+// startFailure records a new failure and returns its 1-based index, or 0 once
+// maxFailures failures have been recorded
+func startFailure(failures *[]Failure, f Failure) int {
+	if len(*failures) >= maxFailures {
+		return 0
+	}
+	*failures = append(*failures, f)
+	return len(*failures)
+}
+
+// atoi converts a regexp capture to an int, returning 0 when empty
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// matchIf runs re over line only when a cheaper check says it can match.
+// Parsers see every line of every log, so the prefilter keeps the regexes
+// off the vast majority of lines.
+func matchIf(ok bool, re *regexp.Regexp, line string) []string {
+	if !ok {
+		return nil
+	}
+	return re.FindStringSubmatch(line)
+}
+
+// ---- go test ----
+
+var (
+	goTestRunRegex      = regexp.MustCompile(`^=== (?:RUN|CONT|NAME)\s+(\S+)`)
+	goTestFailRegex     = regexp.MustCompile(`^\s*--- FAIL: (\S+) \(([\d.]+)s\)`)
+	goTestPassRegex     = regexp.MustCompile(`^\s*--- (?:PASS|SKIP): (\S+)`)
+	goTestLocationRegex = regexp.MustCompile(`^\s+(\S+\.go):(\d+): (.*)$`)
+	goBuildErrorRegex   = regexp.MustCompile(`^(\S+\.go):(\d+):(\d+): (.+)$`)
+)
+
+type goTestExtractor struct{}
+
+func (goTestExtractor) Name() string      { return "go test" }
+func (goTestExtractor) NewParser() Parser { return &goTestParser{output: map[string]Failure{}} }
+
+type goTestParser struct {
+	failures []Failure
+	// current is the 1-based index of the failure receiving output lines
+	current int
+	// running is the test named by the last === RUN/CONT/NAME line
+	running string
+	// output holds what tests logged before their --- FAIL line (go test -v)
+	output map[string]Failure
+}
+
+func (p *goTestParser) Feed(line string) {
+	if strings.HasPrefix(line, "=== ") {
+		if m := goTestRunRegex.FindStringSubmatch(line); m != nil {
+			p.running = m[1]
+			p.current = 0
+			return
+		}
+	}
+	if strings.Contains(line, "--- ") {
+		if m := goTestFailRegex.FindStringSubmatch(line); m != nil {
+			f := p.output[m[1]]
+			delete(p.output, m[1])
+			f.Parser, f.TestID = "go test", m[1]
+			p.current = startFailure(&p.failures, f)
+			return
+		}
+		if m := goTestPassRegex.FindStringSubmatch(line); m != nil {
+			delete(p.output, m[1])
+			p.current = 0
+			return
+		}
+	}
+	hasGoFile := strings.Contains(line, ".go:")
+	if m := matchIf(hasGoFile, goBuildErrorRegex, line); m != nil && len(p.failures) < maxFailures {
+		p.failures = append(p.failures, Failure{
+			Parser:  "go test",
+			File:    m[1],
+			Line:    atoi(m[2]),
+			Column:  atoi(m[3]),
+			Message: m[4],
+		})
+		p.current = 0
+		return
+	}
+
+	trimmed := strings.TrimSpace(line)
+	if trimmed == "" || trimmed == "FAIL" || trimmed == "PASS" ||
+		strings.HasPrefix(trimmed, "FAIL\t") || strings.HasPrefix(trimmed, "ok ") {
+		p.current = 0
+		p.running = ""
+		return
+	}
+
+	var f Failure
+	switch {
+	case p.current > 0:
+		f = p.failures[p.current-1]
+	case p.running != "" && strings.HasPrefix(line, "    "):
+		f = p.output[p.running]
+	default:
+		return
+	}
+
+	if m := matchIf(hasGoFile, goTestLocationRegex, line); m != nil && f.File == "" {
+		f.File, f.Line, f.Message = m[1], atoi(m[2]), m[3]
+	} else {
+		f.Message = appendLine(f.Message, trimmed)
+	}
+
+	if p.current > 0 {
+		p.failures[p.current-1] = f
+	} else {
+		p.output[p.running] = f
+	}
+}
+
+func (p *goTestParser) Failures() []Failure {
+	// Drop parent tests that only failed because one of their subtests did
+	var failures []Failure
+	for _, f := range p.failures {
+		if f.Message == "" && f.TestID != "" && hasSubtestFailure(p.failures, f.TestID) {
+			continue
+		}
+		failures = append(failures, f)
+	}
+	return failures
+}
+
+// ---- jest / vitest ----
+
+var (
+	jestSuiteRegex    = regexp.MustCompile(`^\s*FAIL\s+(\S+)\s*$`)
+	jestTestRegex     = regexp.MustCompile(`^\s*● (.+)$`)
+	jestFrameRegex    = regexp.MustCompile(`^\s*at (?:.* \()?(.+?):(\d+):(\d+)\)?$`)
+	jestCodeRegex     = regexp.MustCompile(`^\s*>?\s*\d+ \|`)
+	jestCaretRegex    = regexp.MustCompile(`^\s*\|`)
+	jestEndRegex      = regexp.MustCompile(`^\s*(PASS|FAIL)\s|^(Test Suites|Tests|Snapshots|Time):`)
+	vitestTestRegex   = regexp.MustCompile(`^\s*FAIL\s+(\S+) > (.+)$`)
+	vitestFrameRegex  = regexp.MustCompile(`^\s*❯ (.+?):(\d+):(\d+)$`)
+	vitestBorderRegex = regexp.MustCompile(`^\s*[⎯-]{3,}`)
+)
+
+type jestExtractor struct{}
+
+func (jestExtractor) Name() string      { return "jest" }
+func (jestExtractor) NewParser() Parser { return &jestParser{} }
+
+type jestParser struct {
+	failures []Failure
+	suite    string
+	current  int
+}
+
+func (p *jestParser) Feed(line string) {
+	hasFail := strings.Contains(line, "FAIL")
+	if m := matchIf(hasFail, vitestTestRegex, line); m != nil {
+		p.current = startFailure(&p.failures, Failure{Parser: "jest", TestID: m[2], File: m[1]})
+		return
+	}
+	if m := matchIf(hasFail, jestSuiteRegex, line); m != nil {
+		p.suite = m[1]
+		p.current = 0
+		return
+	}
+	if m := matchIf(strings.Contains(line, "●"), jestTestRegex, line); m != nil {
+		p.current = startFailure(&p.failures, Failure{Parser: "jest", TestID: m[1], File: p.suite})
+		return
+	}
+	if p.current == 0 {
+		return
+	}
+	if jestEndRegex.MatchString(line) || vitestBorderRegex.MatchString(line) {
+		p.current = 0
+		return
+	}
+
+	f := &p.failures[p.current-1]
+	trimmed := strings.TrimSpace(line)
+	if m := vitestFrameRegex.FindStringSubmatch(line); m != nil {
+		if f.Line == 0 {
+			f.File, f.Line, f.Column = m[1], atoi(m[2]), atoi(m[3])
+		}
+		return
+	}
+	if m := jestFrameRegex.FindStringSubmatch(line); m != nil {
+		f.Stack = appendLine(f.Stack, trimmed)
+		if f.Line == 0 && !strings.Contains(m[1], "node_modules") {
+			f.File, f.Line, f.Column = m[1], atoi(m[2]), atoi(m[3])
+		}
+		return
+	}
+	if trimmed == "" || jestCodeRegex.MatchString(line) || jestCaretRegex.MatchString(line) {
+		return
+	}
+	if f.Stack == "" {
+		f.Message = appendLine(f.Message, trimmed)
+	}
+}
+
+func (p *jestParser) Failures() []Failure {
+	// Jest repeats every failure in its "Summary of all failing tests"
+	seen := make(map[string]bool)
+	var failures []Failure
+	for _, f := range p.failures {
+		key := f.File + "\x00" + f.TestID
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		failures = append(failures, f)
+	}
+	return failures
+}
+
+// ---- tsc ----
+
+var (
+	tscRegex       = regexp.MustCompile(`^(\S.*?\.(?:ts|tsx|mts|cts))\((\d+),(\d+)\): error (TS\d+): (.+)$`)
+	tscPrettyRegex = regexp.MustCompile(`^(\S.*?\.(?:ts|tsx|mts|cts)):(\d+):(\d+) - error (TS\d+): (.+)$`)
+)
+
+type tscExtractor struct{}
+
+func (tscExtractor) Name() string      { return "tsc" }
+func (tscExtractor) NewParser() Parser { return &tscParser{} }
+
+type tscParser struct {
+	failures []Failure
+}
+
+func (p *tscParser) Feed(line string) {
+	if len(p.failures) >= maxFailures || !strings.Contains(line, "error TS") {
+		return
+	}
+	m := tscRegex.FindStringSubmatch(line)
+	if m == nil {
+		m = tscPrettyRegex.FindStringSubmatch(line)
+	}
+	if m == nil {
+		return
+	}
+	p.failures = append(p.failures, Failure{
+		Parser:  "tsc",
+		TestID:  m[4],
+		File:    m[1],
+		Line:    atoi(m[2]),
+		Column:  atoi(m[3]),
+		Message: m[5],
+	})
+}
+
+func (p *tscParser) Failures() []Failure {
+	return p.failures
+}
+
+// ---- eslint ----
+
+var (
+	eslintFileRegex  = regexp.MustCompile(`^(\S.*\.(?:js|jsx|ts|tsx|mjs|cjs|vue))$`)
+	eslintIssueRegex = regexp.MustCompile(`^\s+(\d+):(\d+)\s+error\s+(.+?)(?:\s{2,}(\S+))?$`)
+)
+
+// eslintExtensions are the extensions matched by eslintFileRegex
+var eslintExtensions = map[string]bool{
+	".js": true, ".jsx": true, ".ts": true, ".tsx": true, ".mjs": true, ".cjs": true, ".vue": true,
+}
+
+type eslintExtractor struct{}
+
+func (eslintExtractor) Name() string      { return "eslint" }
+func (eslintExtractor) NewParser() Parser { return &eslintParser{} }
+
+type eslintParser struct {
+	failures []Failure
+	file     string
+}
+
+func (p *eslintParser) Feed(line string) {
+	if len(p.failures) >= maxFailures {
+		return
+	}
+	if m := matchIf(eslintExtensions[path.Ext(line)], eslintFileRegex, line); m != nil {
+		p.file = m[1]
+		return
+	}
+	if p.file == "" {
+		return
+	}
+	if m := matchIf(strings.Contains(line, "error"), eslintIssueRegex, line); m != nil {
+		p.failures = append(p.failures, Failure{
+			Parser:  "eslint",
+			TestID:  m[4],
+			File:    p.file,
+			Line:    atoi(m[1]),
+			Column:  atoi(m[2]),
+			Message: m[3],
+		})
+		return
+	}
+	if strings.TrimSpace(line) == "" || !strings.HasPrefix(line, " ") {
+		p.file = ""
+	}
+}
+
+func (p *eslintParser) Failures() []Failure {
+	return p.failures
+}
+
+// ---- pytest ----
+
+var (
+	pytestSectionRegex  = regexp.MustCompile(`^={3,} (FAILURES|ERRORS|short test summary info|.*) ={3,}$`)
+	pytestHeaderRegex   = regexp.MustCompile(`^_{3,} (.+?) _{3,}$`)
+	pytestErrorRegex    = regexp.MustCompile(`^E\s+(.*)$`)
+	pytestLocationRegex = regexp.MustCompile(`^(\S+\.py):(\d+): (\w+)$`)
+	pytestSummaryRegex  = regexp.MustCompile(`^(FAILED|ERROR) (\S+?)(?: - (.*))?$`)
+)
+
+type pytestExtractor struct{}
+
+func (pytestExtractor) Name() string      { return "pytest" }
+func (pytestExtractor) NewParser() Parser { return &pytestParser{} }
+
+type pytestParser struct {
+	section string
+	blocks  []Failure
+	summary []Failure
+}
+
+func (p *pytestParser) Feed(line string) {
+	if m := matchIf(strings.HasPrefix(line, "===") && strings.HasSuffix(line, "==="), pytestSectionRegex, line); m != nil {
+		p.section = m[1]
+		return
+	}
+
+	switch p.section {
+	case "FAILURES", "ERRORS":
+		if m := matchIf(strings.HasPrefix(line, "___"), pytestHeaderRegex, line); m != nil && len(p.blocks) < maxFailures {
+			p.blocks = append(p.blocks, Failure{Parser: "pytest", TestID: m[1]})
+			return
+		}
+		if len(p.blocks) == 0 {
+			return
+		}
+		b := &p.blocks[len(p.blocks)-1]
+		if m := matchIf(strings.HasPrefix(line, "E"), pytestErrorRegex, line); m != nil {
+			b.Message = appendLine(b.Message, m[1])
+		} else if m := matchIf(strings.Contains(line, ".py:"), pytestLocationRegex, line); m != nil {
+			b.File, b.Line = m[1], atoi(m[2])
+		}
+	case "short test summary info":
+		if m := matchIf(strings.HasPrefix(line, "FAILED ") || strings.HasPrefix(line, "ERROR "), pytestSummaryRegex, line); m != nil && len(p.summary) < maxFailures {
+			f := Failure{Parser: "pytest", TestID: m[2], Message: m[3]}
+			if i := strings.Index(m[2], "::"); i >= 0 {
+				f.File = m[2][:i]
+			}
+			p.summary = append(p.summary, f)
+		}
+	}
+}
+
+func (p *pytestParser) Failures() []Failure {
+	if len(p.summary) == 0 {
+		return p.blocks
+	}
+
+	// Enrich the summary entries with the details from the FAILURES section,
+	// whose headers name tests as "test_x" or "TestClass.test_x"
+	failures := make([]Failure, 0, len(p.summary))
+	for _, f := range p.summary {
+		name := strings.ReplaceAll(f.TestID, "::", ".")
+		for _, b := range p.blocks {
+			if !hasNameSuffix(name, b.TestID) {
+				continue
+			}
+			if b.Message != "" {
+				f.Message = b.Message
+			}
+			if b.File != "" {
+				f.File, f.Line = b.File, b.Line
+			}
+			break
+		}
+		failures = append(failures, f)
+	}
+	return failures
+}
+
+// hasNameSuffix reports whether name ends with the dotted name suffix, whole
+// parts only, so that test_add does not claim my_test_add
+func hasNameSuffix(name, suffix string) bool {
+	if !strings.HasSuffix(name, suffix) {
+		return false
+	}
+	rest := name[:len(name)-len(suffix)]
+	return rest == "" || strings.HasSuffix(rest, ".")
+}
+
+// ---- JUnit (Maven Surefire / Gradle) ----
+
+var (
+	surefireRegex   = regexp.MustCompile(`^\[ERROR\] (\S+?)(?:\((\S+)\))?\s+(?:-- )?Time elapsed: .*<<< (?:FAILURE|ERROR)!$`)
+	gradleFailRegex = regexp.MustCompile(`^(\S+) > (.+) FAILED$`)
+	javaFrameRegex  = regexp.MustCompile(`^\s*at (?:\S+//)?(\S+)\((\w+\.(?:java|kt|groovy|scala)):(\d+)\)$`)
+)
+
+type junitExtractor struct{}
+
+func (junitExtractor) Name() string      { return "junit" }
+func (junitExtractor) NewParser() Parser { return &junitParser{} }
+
+type junitParser struct {
+	failures []Failure
+	current  int
+}
+
+func (p *junitParser) Feed(line string) {
+	if m := matchIf(strings.Contains(line, "<<< "), surefireRegex, line); m != nil {
+		testID := m[1]
+		if m[2] != "" {
+			testID = m[2] + "." + m[1]
+		}
+		p.current = startFailure(&p.failures, Failure{Parser: "junit", TestID: testID})
+		return
+	}
+	if m := matchIf(strings.HasSuffix(line, " FAILED"), gradleFailRegex, line); m != nil {
+		p.current = startFailure(&p.failures, Failure{Parser: "junit", TestID: m[1] + "." + m[2]})
+		return
+	}
+	if p.current == 0 {
+		return
+	}
+
+	f := &p.failures[p.current-1]
+	trimmed := strings.TrimSpace(line)
+	if m := matchIf(strings.HasPrefix(trimmed, "at "), javaFrameRegex, line); m != nil {
+		f.Stack = appendLine(f.Stack, trimmed)
+		if f.Line == 0 && strings.Contains(f.TestID, simpleName(classOf(m[1]))) {
+			f.File, f.Line = m[2], atoi(m[3])
+		}
+		return
+	}
+	if trimmed == "" || strings.HasPrefix(line, "[") || f.Stack != "" {
+		p.current = 0
+		return
+	}
+	f.Message = appendLine(f.Message, trimmed)
+}
+
+// classOf returns the class part of a fully-qualified Java method name
+func classOf(method string) string {
+	if i := strings.LastIndex(method, "."); i >= 0 {
+		return method[:i]
+	}
+	return method
+}
+
+// simpleName strips the package from a fully-qualified Java class name
+func simpleName(class string) string {
+	return class[strings.LastIndex(class, ".")+1:]
+}
+
+func (p *junitParser) Failures() []Failure {
+	return p.failures
+}
+
+// ---- cargo test ----
+
+var (
+	cargoTestRegex      = regexp.MustCompile(`^---- (\S+) stdout ----$`)
+	cargoPanicRegex     = regexp.MustCompile(`panicked at (.+?):(\d+):(\d+):$`)
+	cargoOldPanicRegex  = regexp.MustCompile(`panicked at '(.*)', (.+?):(\d+):(\d+)$`)
+	cargoCompileRegex   = regexp.MustCompile(`^error(\[E\d+\])?: (.+)$`)
+	cargoLocationRegex  = regexp.MustCompile(`^\s*--> (.+?):(\d+):(\d+)$`)
+	cargoSummaryMarkers = []string{"error: could not compile", "error: test failed", "error: aborting"}
+)
+
+type cargoExtractor struct{}
+
+func (cargoExtractor) Name() string      { return "cargo test" }
+func (cargoExtractor) NewParser() Parser { return &cargoParser{} }
+
+type cargoParser struct {
+	failures []Failure
+	current  int
+	inPanic  bool
+}
+
+func (p *cargoParser) Feed(line string) {
+	if m := matchIf(strings.HasPrefix(line, "---- "), cargoTestRegex, line); m != nil {
+		p.start(Failure{Parser: "cargo test", TestID: m[1]})
+		p.inPanic = false
+		return
+	}
+	if m := matchIf(strings.HasPrefix(line, "error"), cargoCompileRegex, line); m != nil {
+		for _, marker := range cargoSummaryMarkers {
+			if strings.HasPrefix(line, marker) {
+				p.current = 0
+				return
+			}
+		}
+		p.start(Failure{Parser: "cargo test", TestID: strings.Trim(m[1], "[]"), Message: m[2]})
+		p.inPanic = false
+		return
+	}
+	if p.current == 0 {
+		return
+	}
+
+	f := &p.failures[p.current-1]
+	if m := cargoLocationRegex.FindStringSubmatch(line); m != nil && f.Line == 0 {
+		f.File, f.Line, f.Column = m[1], atoi(m[2]), atoi(m[3])
+		p.current = 0
+		return
+	}
+	if m := cargoOldPanicRegex.FindStringSubmatch(line); m != nil {
+		f.Message = m[1]
+		f.File, f.Line, f.Column = m[2], atoi(m[3]), atoi(m[4])
+		return
+	}
+	if m := cargoPanicRegex.FindStringSubmatch(line); m != nil {
+		f.File, f.Line, f.Column = m[1], atoi(m[2]), atoi(m[3])
+		p.inPanic = true
+		return
+	}
+	if p.inPanic {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "note:") {
+			p.current = 0
+			p.inPanic = false
+			return
+		}
+		f.Message = appendLine(f.Message, line)
+	}
+}
+
+// start records a new failure. The previous one is dropped first if it never
+// got a location, as Failures would drop it, so that bare "error: ..." lines
+// cannot use up maxFailures.
+func (p *cargoParser) start(f Failure) {
+	if n := len(p.failures); n > 0 && p.failures[n-1].File == "" {
+		p.failures = p.failures[:n-1]
+	}
+	p.current = startFailure(&p.failures, f)
+}
+
+func (p *cargoParser) Failures() []Failure {
+	// Bare "error: ..." lines come from many tools; only keep the compiler
+	// errors that rustc followed with a "--> file:line:col" location
+	var failures []Failure
+	for _, f := range p.failures {
+		if f.File != "" {
+			failures = append(failures, f)
+		}
+	}
+	return failures
+}
@@ -0,0 +1,261 @@
+package github
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runParser feeds a log through a single extractor's parser
+func runParser(e Extractor, logs string) []Failure {
+	p := e.NewParser()
+	for _, line := range strings.Split(logs, "\n") {
+		p.Feed(line)
+	}
+	return p.Failures()
+}
+
+func TestParsers(t *testing.T) {
+	tests := []struct {
+		name      string
+		extractor Extractor
+		logs      string
+		expected  []Failure
+	}{
+		{
+			name:      "go test",
+			extractor: goTestExtractor{},
+			logs: `--- FAIL: TestAdd (0.00s)
+    --- FAIL: TestAdd/positive_numbers (0.00s)
+        math_test.go:47: Add(2, 3) = 6; expected 5
+FAIL
+FAIL	github.com/srt32/copilot-actions-looper/testapp	0.003s`,
+			expected: []Failure{
+				{Parser: "go test", TestID: "TestAdd/positive_numbers", File: "math_test.go", Line: 47, Message: "Add(2, 3) = 6; expected 5"},
+			},
+		},
+		{
+			name:      "go test -v",
+			extractor: goTestExtractor{},
+			logs: `=== RUN   TestAdd
+=== RUN   TestAdd/positive_numbers
+    math_test.go:47: Add(2, 3) = 6; expected 5
+=== RUN   TestAdd/zero_values
+--- FAIL: TestAdd (0.00s)
+    --- FAIL: TestAdd/positive_numbers (0.00s)
+    --- PASS: TestAdd/zero_values (0.00s)
+FAIL`,
+			expected: []Failure{
+				{Parser: "go test", TestID: "TestAdd/positive_numbers", File: "math_test.go", Line: 47, Message: "Add(2, 3) = 6; expected 5"},
+			},
+		},
+		{
+			name:      "go build error",
+			extractor: goTestExtractor{},
+			logs: `# github.com/srt32/copilot-actions-looper/testapp
+./math.go:5:9: undefined: c
+FAIL	github.com/srt32/copilot-actions-looper/testapp [build failed]`,
+			expected: []Failure{
+				{Parser: "go test", File: "./math.go", Line: 5, Column: 9, Message: "undefined: c"},
+			},
+		},
+		{
+			name:      "jest",
+			extractor: jestExtractor{},
+			logs: `FAIL src/math.test.ts
+  ● math › adds numbers
+
+    expect(received).toBe(expected) // Object.is equality
+
+    Expected: 4
+    Received: 3
+
+       9 |   it('adds numbers', () => {
+    > 10 |     expect(add(1, 2)).toBe(4);
+         |                       ^
+      11 |   });
+
+      at Object.<anonymous> (src/math.test.ts:10:23)
+
+Test Suites: 1 failed, 1 total`,
+			expected: []Failure{
+				{
+					Parser:  "jest",
+					TestID:  "math › adds numbers",
+					File:    "src/math.test.ts",
+					Line:    10,
+					Column:  23,
+					Message: "expect(received).toBe(expected) // Object.is equality\nExpected: 4\nReceived: 3",
+					Stack:   "at Object.<anonymous> (src/math.test.ts:10:23)",
+				},
+			},
+		},
+		{
+			name:      "vitest",
+			extractor: jestExtractor{},
+			logs: ` FAIL  src/math.test.ts > math > adds numbers
+AssertionError: expected 3 to be 4 // Object.is equality
+ ❯ src/math.test.ts:10:23
+⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯⎯[1/1]⎯`,
+			expected: []Failure{
+				{Parser: "jest", TestID: "math > adds numbers", File: "src/math.test.ts", Line: 10, Column: 23, Message: "AssertionError: expected 3 to be 4 // Object.is equality"},
+			},
+		},
+		{
+			name:      "tsc",
+			extractor: tscExtractor{},
+			logs: `src/index.ts(3,7): error TS2322: Type 'string' is not assignable to type 'number'.
+src/util.ts:12:5 - error TS2304: Cannot find name 'foo'.`,
+			expected: []Failure{
+				{Parser: "tsc", TestID: "TS2322", File: "src/index.ts", Line: 3, Column: 7, Message: "Type 'string' is not assignable to type 'number'."},
+				{Parser: "tsc", TestID: "TS2304", File: "src/util.ts", Line: 12, Column: 5, Message: "Cannot find name 'foo'."},
+			},
+		},
+		{
+			name:      "eslint",
+			extractor: eslintExtractor{},
+			logs: `/home/runner/work/app/app/src/index.js
+  1:10  error    'foo' is defined but never used  no-unused-vars
+  2:1   warning  Unexpected console statement     no-console
+
+✖ 2 problems (1 error, 1 warning)`,
+			expected: []Failure{
+				{Parser: "eslint", TestID: "no-unused-vars", File: "/home/runner/work/app/app/src/index.js", Line: 1, Column: 10, Message: "'foo' is defined but never used"},
+			},
+		},
+		{
+			name:      "pytest",
+			extractor: pytestExtractor{},
+			logs: `=================================== FAILURES ===================================
+___________________________________ test_add ___________________________________
+
+    def test_add():
+>       assert add(1, 2) == 4
+E       assert 3 == 4
+E        +  where 3 = add(1, 2)
+
+tests/test_math.py:5: AssertionError
+=========================== short test summary info ============================
+FAILED tests/test_math.py::test_add - assert 3 == 4
+============================== 1 failed in 0.02s ===============================`,
+			expected: []Failure{
+				{Parser: "pytest", TestID: "tests/test_math.py::test_add", File: "tests/test_math.py", Line: 5, Message: "assert 3 == 4\n+  where 3 = add(1, 2)"},
+			},
+		},
+		{
+			name:      "pytest names ending the same way",
+			extractor: pytestExtractor{},
+			logs: `=================================== FAILURES ===================================
+___________________________________ test_add ___________________________________
+E       assert 3 == 4
+
+tests/test_math.py:5: AssertionError
+_________________________________ my_test_add __________________________________
+E       assert 1 == 2
+
+tests/test_math.py:12: AssertionError
+=========================== short test summary info ============================
+FAILED tests/test_math.py::my_test_add - assert 1 == 2
+FAILED tests/test_math.py::test_add - assert 3 == 4`,
+			expected: []Failure{
+				{Parser: "pytest", TestID: "tests/test_math.py::my_test_add", File: "tests/test_math.py", Line: 12, Message: "assert 1 == 2"},
+				{Parser: "pytest", TestID: "tests/test_math.py::test_add", File: "tests/test_math.py", Line: 5, Message: "assert 3 == 4"},
+			},
+		},
+		{
+			name:      "maven surefire",
+			extractor: junitExtractor{},
+			logs: `[ERROR] Tests run: 1, Failures: 1, Errors: 0, Skipped: 0, Time elapsed: 0.05 s <<< FAILURE! - in com.example.AppTest
+[ERROR] testAdd(com.example.AppTest)  Time elapsed: 0.01 s  <<< FAILURE!
+org.opentest4j.AssertionFailedError: expected: <4> but was: <3>
+	at org.junit.jupiter.api.AssertionUtils.fail(AssertionUtils.java:55)
+	at com.example.AppTest.testAdd(AppTest.java:12)
+
+[INFO] Results:`,
+			expected: []Failure{
+				{
+					Parser:  "junit",
+					TestID:  "com.example.AppTest.testAdd",
+					File:    "AppTest.java",
+					Line:    12,
+					Message: "org.opentest4j.AssertionFailedError: expected: <4> but was: <3>",
+					Stack:   "at org.junit.jupiter.api.AssertionUtils.fail(AssertionUtils.java:55)\nat com.example.AppTest.testAdd(AppTest.java:12)",
+				},
+			},
+		},
+		{
+			name:      "gradle",
+			extractor: junitExtractor{},
+			logs: `AppTest > testAdd() FAILED
+    org.opentest4j.AssertionFailedError: expected: <4> but was: <3>
+        at app//com.example.AppTest.testAdd(AppTest.java:12)
+
+1 test completed, 1 failed`,
+			expected: []Failure{
+				{
+					Parser:  "junit",
+					TestID:  "AppTest.testAdd()",
+					File:    "AppTest.java",
+					Line:    12,
+					Message: "org.opentest4j.AssertionFailedError: expected: <4> but was: <3>",
+					Stack:   "at app//com.example.AppTest.testAdd(AppTest.java:12)",
+				},
+			},
+		},
+		{
+			name:      "cargo test",
+			extractor: cargoExtractor{},
+			logs: `---- tests::it_adds stdout ----
+thread 'tests::it_adds' panicked at src/lib.rs:10:9:
+assertion ` + "`left == right`" + ` failed
+  left: 3
+ right: 4
+note: run with ` + "`RUST_BACKTRACE=1`" + ` environment variable to display a backtrace
+
+failures:
+    tests::it_adds
+
+error: test failed, to rerun pass ` + "`--lib`",
+			expected: []Failure{
+				{Parser: "cargo test", TestID: "tests::it_adds", File: "src/lib.rs", Line: 10, Column: 9, Message: "assertion `left == right` failed\n  left: 3\n right: 4"},
+			},
+		},
+		{
+			name:      "rustc error",
+			extractor: cargoExtractor{},
+			logs: `error[E0425]: cannot find value ` + "`x`" + ` in this scope
+ --> src/lib.rs:3:5
+  |
+3 |     x
+  |     ^ not found in this scope
+error: could not compile ` + "`app`",
+			expected: []Failure{
+				{Parser: "cargo test", TestID: "E0425", File: "src/lib.rs", Line: 3, Column: 5, Message: "cannot find value `x` in this scope"},
+			},
+		},
+		{
+			name:      "rustc error after many unrelated error lines",
+			extractor: cargoExtractor{},
+			logs: strings.Repeat("error: could not find `Cargo.toml`\n", maxFailures+10) + `error[E0308]: mismatched types
+ --> src/main.rs:4:18`,
+			expected: []Failure{
+				{Parser: "cargo test", TestID: "E0308", File: "src/main.rs", Line: 4, Column: 18, Message: "mismatched types"},
+			},
+		},
+		{
+			name:      "unrelated error lines",
+			extractor: cargoExtractor{},
+			logs:      "error: pathspec 'main' did not match any file(s) known to git",
+			expected:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := runParser(tt.extractor, tt.logs)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("Expected:\n%+v\n\nGot:\n%+v", tt.expected, result)
+			}
+		})
+	}
+}
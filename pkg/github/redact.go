@@ -0,0 +1,151 @@
+package github
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// redactedText replaces secrets found in log text
+const redactedText = "[REDACTED]"
+
+// redactionRule is a pattern for a kind of secret. When the pattern has a
+// group named "secret", only that group is replaced, keeping context such as
+// the key name or the user of a connection string.
+type redactionRule struct {
+	name string
+	re   *regexp.Regexp
+}
+
+// builtinRedactionRules cover credentials that tools commonly print and that
+// Actions does not mask because they are derived rather than stored secrets
+var builtinRedactionRules = []redactionRule{
+	{name: "private key", re: regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(-----END [A-Z ]*PRIVATE KEY-----|\z)`)},
+	{name: "GitHub token", re: regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{name: "AWS access key", re: regexp.MustCompile(`\b(AKIA|ASIA)[A-Z0-9]{16}\b`)},
+	{name: "AWS secret key", re: regexp.MustCompile(`(?i)aws_?secret_?access_?key["']?\s*[:=]\s*["']?(?P<secret>[A-Za-z0-9/+=]{40})`)},
+	{name: "Slack token", re: regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{name: "Stripe key", re: regexp.MustCompile(`\b[sr]k_live_[A-Za-z0-9]{16,}`)},
+	{name: "Google API key", re: regexp.MustCompile(`\bAIza[A-Za-z0-9_-]{35}`)},
+	{name: "npm token", re: regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`)},
+	{name: "JWT", re: regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]+`)},
+	{name: "authorization header", re: regexp.MustCompile(`(?i)\b(bearer|basic)\s+(?P<secret>[A-Za-z0-9._~+/-]{16,}=*)`)},
+	{name: "URL password", re: regexp.MustCompile(`\b[a-zA-Z][a-zA-Z0-9+.-]*://[^:/?#@\s]+:(?P<secret>[^@/\s]+)@`)},
+	{name: "connection string password", re: regexp.MustCompile(`(?i)\b(password|pwd)=(?P<secret>[^;\s"']+)`)},
+	{name: "secret assignment", re: regexp.MustCompile(`(?i)\b[\w.-]*(password|passwd|secret|token|api_?key|access_?key|private_?key)["']?\s*[:=]\s*["']?(?P<secret>[^\s"',;]{8,})`)},
+}
+
+// Redactor removes credentials from log text before it is published
+type Redactor struct {
+	rules   []redactionRule
+	entropy bool
+}
+
+// NewRedactor returns a redactor with the built-in rules, the extra patterns
+// given, and, when entropy is set, detection of random-looking tokens
+func NewRedactor(patterns []*regexp.Regexp, entropy bool) *Redactor {
+	rules := append([]redactionRule{}, builtinRedactionRules...)
+	for _, re := range patterns {
+		rules = append(rules, redactionRule{name: "custom", re: re})
+	}
+	return &Redactor{rules: rules, entropy: entropy}
+}
+
+// Redact replaces every secret found in text
+func (r *Redactor) Redact(text string) string {
+	if text == "" {
+		return text
+	}
+	for _, rule := range r.rules {
+		text = redactMatches(rule.re, text)
+	}
+	if r.entropy {
+		text = highEntropyRegex.ReplaceAllStringFunc(text, func(token string) string {
+			if isHighEntropy(token) {
+				return redactedText
+			}
+			return token
+		})
+	}
+	return text
+}
+
+// redactMatches replaces the matches of re in text, or only their "secret"
+// group when re has one
+func redactMatches(re *regexp.Regexp, text string) string {
+	group := re.SubexpIndex("secret")
+	if group < 0 {
+		return re.ReplaceAllString(text, redactedText)
+	}
+
+	var sb strings.Builder
+	last := 0
+	for _, m := range re.FindAllStringSubmatchIndex(text, -1) {
+		start, end := m[2*group], m[2*group+1]
+		if start < 0 {
+			continue
+		}
+		sb.WriteString(text[last:start])
+		sb.WriteString(redactedText)
+		last = end
+	}
+	sb.WriteString(text[last:])
+	return sb.String()
+}
+
+// highEntropyRegex finds candidate tokens for entropy-based detection.
+// Slashes and dots are left out so that paths and hostnames split apart.
+var highEntropyRegex = regexp.MustCompile(`[A-Za-z0-9+_=-]{24,}`)
+
+// minTokenEntropy is the Shannon entropy, in bits per character, above which
+// a token is taken to be random. Hex strings such as commit SHAs stay below
+// it, since hex carries at most 4 bits per character.
+const minTokenEntropy = 4.2
+
+// isHighEntropy reports whether a token looks like a random credential: it
+// mixes upper case, lower case and digits, its lower case letters come in
+// short runs rather than the words of an identifier, and its characters are
+// spread evenly enough to be random
+func isHighEntropy(token string) bool {
+	var upper, digit bool
+	lower, lowerRuns := 0, 0
+	inLower := false
+	counts := make(map[rune]int)
+	for _, c := range token {
+		isLower := unicode.IsLower(c)
+		if isLower {
+			lower++
+			if !inLower {
+				lowerRuns++
+			}
+		}
+		inLower = isLower
+		upper = upper || unicode.IsUpper(c)
+		digit = digit || unicode.IsDigit(c)
+		counts[c]++
+	}
+	if !upper || !digit || lower == 0 || lower >= 3*lowerRuns {
+		return false
+	}
+
+	entropy := 0.0
+	n := float64(len(token))
+	for _, count := range counts {
+		p := float64(count) / n
+		entropy -= p * math.Log2(p)
+	}
+	return entropy >= minTokenEntropy
+}
+
+// redacted returns a copy of the report with secrets removed from all text
+// taken from CI output
+func (r *FailureReport) redacted(redactor *Redactor) *FailureReport {
+	redacted := r.mapText(redactor.Redact)
+	// Reproductions come from the workflow file, but can still hold
+	// credentials written into it
+	for i := range redacted.Jobs {
+		redacted.Jobs[i].Reproduction = redactor.Redact(redacted.Jobs[i].Reproduction)
+	}
+	return redacted
+}
@@ -0,0 +1,239 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// maxReviewComments bounds the inline comments of one review; the summary
+// comment still lists every failure
+const maxReviewComments = 30
+
+// maxPullRequestFilePages bounds the pages of changed files read, at 100 per
+// page. The API lists at most 3000 files.
+const maxPullRequestFilePages = 30
+
+// pullRequestDiff maps each file changed by a pull request to the lines of
+// its new version that appear in the diff, which are the lines a review
+// comment can be placed on
+type pullRequestDiff map[string]map[int]bool
+
+// hunkHeaderRegex matches a hunk header, capturing the first line of the new
+// version
+var hunkHeaderRegex = regexp.MustCompile(`^@@ -\d+(?:,\d+)? \+(\d+)(?:,\d+)? @@`)
+
+// parsePatchLines returns the lines of the new version of a file that appear
+// in its patch, both added lines and context
+func parsePatchLines(patch string) map[int]bool {
+	lines := make(map[int]bool)
+	next := 0
+	for _, line := range strings.Split(patch, "\n") {
+		if m := hunkHeaderRegex.FindStringSubmatch(line); m != nil {
+			next, _ = strconv.Atoi(m[1])
+			continue
+		}
+		if next == 0 || line == "" {
+			continue
+		}
+		switch line[0] {
+		case '+', ' ':
+			lines[next] = true
+			next++
+		}
+	}
+	return lines
+}
+
+// getPullRequestDiff fetches the files changed by a pull request and the
+// lines of each that appear in its diff
+func (c *Client) getPullRequestDiff(ctx context.Context, prNumber int) (pullRequestDiff, error) {
+	diff := make(pullRequestDiff)
+	for page := 1; page <= maxPullRequestFilePages; page++ {
+		url := fmt.Sprintf("%s/repos/%s/pulls/%d/files?per_page=100&page=%d", c.baseURL, c.repository, prNumber, page)
+		var files []PullRequestFile
+		if err := c.getJSON(ctx, url, &files); err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if f.Status != "removed" && f.Patch != "" {
+				diff[f.Filename] = parsePatchLines(f.Patch)
+			}
+		}
+		if len(files) < 100 {
+			break
+		}
+	}
+	return diff, nil
+}
+
+// locate returns the changed file and line a failure points at, if that
+// line appears in the diff
+func (d pullRequestDiff) locate(c *Client, f Failure) (string, int, bool) {
+	if f.Line <= 0 {
+		return "", 0, false
+	}
+
+	file, ok := c.repositoryPath(f)
+	if !ok && f.Parser == "go test" && f.File != "" && !strings.Contains(f.File, "/") {
+		// go test names files relative to their package, so look for a
+		// changed file of that name
+		for changed := range d {
+			if path.Base(changed) == f.File {
+				if ok {
+					return "", 0, false
+				}
+				file, ok = changed, true
+			}
+		}
+	}
+	if !ok || !d[file][f.Line] {
+		return "", 0, false
+	}
+	return file, f.Line, true
+}
+
+// reviewComments places the failures of a report on the lines of the diff
+// they point at, one comment per line. Failures elsewhere are left to the
+// summary comment.
+func (c *Client) reviewComments(report *FailureReport, diff pullRequestDiff) []ReviewComment {
+	type location struct {
+		file string
+		line int
+	}
+	var failures []Failure
+	failures = append(failures, report.TestResults...)
+	for _, job := range report.Jobs {
+		failures = append(failures, job.Failures...)
+	}
+
+	var locations []location
+	byLocation := make(map[location][]Failure)
+	for _, f := range failures {
+		file, line, ok := diff.locate(c, f)
+		if !ok {
+			continue
+		}
+		loc := location{file, line}
+		if _, seen := byLocation[loc]; !seen {
+			locations = append(locations, loc)
+		}
+		byLocation[loc] = appendFailure(byLocation[loc], f)
+	}
+	sort.Slice(locations, func(i, j int) bool {
+		if locations[i].file != locations[j].file {
+			return locations[i].file < locations[j].file
+		}
+		return locations[i].line < locations[j].line
+	})
+	if len(locations) > maxReviewComments {
+		locations = locations[:maxReviewComments]
+	}
+
+	comments := make([]ReviewComment, len(locations))
+	for i, loc := range locations {
+		comments[i] = ReviewComment{
+			Path: loc.file,
+			Line: loc.line,
+			Side: "RIGHT",
+			Body: fitReviewCommentBody(byLocation[loc], maxCommentLength),
+		}
+	}
+	return comments
+}
+
+// appendFailure adds a failure unless the same failure, as reported by
+// several jobs or by both logs and annotations, is already there
+func appendFailure(failures []Failure, f Failure) []Failure {
+	for _, existing := range failures {
+		if existing.TestID == f.TestID && existing.Message == f.Message {
+			return failures
+		}
+	}
+	return append(failures, f)
+}
+
+// fitReviewCommentBody describes the failures at one line in at most limit
+// bytes, trimming their details at each trim level in turn the way
+// fitComment trims a report
+func fitReviewCommentBody(failures []Failure, limit int) string {
+	body := reviewCommentBody(failures)
+	if len(body) <= limit {
+		return body
+	}
+
+	for _, limits := range trimLevels {
+		// Every failure at a line shares the comment, so none is dropped
+		limits.failures = noLimit
+		trimmed, _ := trimFailures(failures, limits)
+		if body = reviewCommentBody(trimmed); len(body) <= limit {
+			return body
+		}
+	}
+	// The cut always takes the end marker, so it is added back
+	return truncateComment(body, limit-len(untrustedEnd)-1) + untrustedEnd + "\n"
+}
+
+// reviewCommentBody describes the failures at one line
+func reviewCommentBody(failures []Failure) string {
+	var sb strings.Builder
+	sb.WriteString(untrustedBegin + "\n")
+	for i, f := range failures {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString("❌ " + f.Parser)
+		if f.TestID != "" {
+			sb.WriteString(": " + inlineCode(f.TestID))
+		}
+		sb.WriteString("\n")
+		if f.Message != "" {
+			sb.WriteString(codeBlock(f.Message, ""))
+		}
+	}
+	sb.WriteString(untrustedEnd + "\n")
+	return sb.String()
+}
+
+// createFailureReview posts a review with an inline comment on each line of
+// the pull request's diff that a failure points at. The report must already
+// be redacted and sanitized.
+//
+// The diff is that of the pull request's current head, so no review is
+// posted once the head has moved past the commit the workflow ran on: the
+// lines would no longer match.
+func (c *Client) createFailureReview(ctx context.Context, prNumber int, workflow *WorkflowRun, report *FailureReport) error {
+	var pr PullRequest
+	if err := c.getJSON(ctx, fmt.Sprintf("%s/repos/%s/pulls/%d", c.baseURL, c.repository, prNumber), &pr); err != nil {
+		return fmt.Errorf("failed to get pull request: %w", err)
+	}
+	if pr.Head.SHA != workflow.HeadSHA {
+		c.logger.Info("The PR has moved past the failed commit, skipping inline comments",
+			"pr", prNumber, "run_sha", workflow.HeadSHA, "head_sha", pr.Head.SHA)
+		return nil
+	}
+
+	diff, err := c.getPullRequestDiff(ctx, prNumber)
+	if err != nil {
+		return fmt.Errorf("failed to get pull request files: %w", err)
+	}
+
+	comments := c.reviewComments(report, diff)
+	if len(comments) == 0 {
+		c.logger.Info("No failures point at lines changed by the PR", "pr", prNumber)
+		return nil
+	}
+	c.logger.Info("Commenting on changed lines", "pr", prNumber, "comments", len(comments))
+
+	url := fmt.Sprintf("%s/repos/%s/pulls/%d/reviews", c.baseURL, c.repository, prNumber)
+	return c.postJSON(url, Review{
+		CommitID: workflow.HeadSHA,
+		Event:    "COMMENT",
+		Body:     fmt.Sprintf("Workflow '%s' failed on lines changed in this pull request. See the PR comments for the full report.", workflow.Name),
+		Comments: comments,
+	})
+}
@@ -0,0 +1,198 @@
+package github
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const samplePatch = "@@ -1,4 +1,5 @@\n" +
+	" package math\n" +
+	" \n" +
+	"-func Add(a, b int) int { return a - b }\n" +
+	"+// Add returns the sum of a and b\n" +
+	"+func Add(a, b int) int { return a * b }\n" +
+	" \n" +
+	`@@ -20,3 +21,3 @@ func Sub(a, b int) int {
+ 	return a - b
+-}
++}
+\ No newline at end of file`
+
+func TestParsePatchLines(t *testing.T) {
+	lines := parsePatchLines(samplePatch)
+	expected := map[int]bool{1: true, 2: true, 3: true, 4: true, 5: true, 21: true, 22: true}
+	if !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Expected %v, got %v", expected, lines)
+	}
+}
+
+func TestReviewComments(t *testing.T) {
+	client := NewClient("test-token", "owner/repo")
+	diff := pullRequestDiff{
+		"math/math.go":        parsePatchLines(samplePatch),
+		"math/math_test.go":   {12: true},
+		"util/helper.go":      {7: true},
+		"other/helper.go":     {7: true},
+		"src/components/a.ts": {3: true},
+	}
+
+	tests := []struct {
+		name     string
+		failure  Failure
+		expected []string // path:line of the comments
+	}{
+		{name: "relative path in the diff", failure: Failure{Parser: "tsc", File: "src/components/a.ts", Line: 3, Message: "TS2322"}, expected: []string{"src/components/a.ts:3"}},
+		{name: "workspace path in the diff", failure: Failure{Parser: "stack trace", File: "/home/runner/work/repo/repo/math/math.go", Line: 5, Message: "panic"}, expected: []string{"math/math.go:5"}},
+		{name: "go test base name", failure: Failure{Parser: "go test", TestID: "TestAdd", File: "math_test.go", Line: 12, Message: "got 6"}, expected: []string{"math/math_test.go:12"}},
+		{name: "ambiguous base name", failure: Failure{Parser: "go test", File: "helper.go", Line: 7, Message: "boom"}},
+		{name: "line outside the diff", failure: Failure{Parser: "tsc", File: "math/math.go", Line: 10, Message: "unused"}},
+		{name: "file outside the diff", failure: Failure{Parser: "eslint", File: "src/index.ts", Line: 3, Message: "no-undef"}},
+		{name: "no line", failure: Failure{Parser: "tsc", File: "src/components/a.ts", Message: "config"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := &FailureReport{Jobs: []JobReport{{Failures: []Failure{tt.failure}}}}
+			var got []string
+			for _, comment := range client.reviewComments(report, diff) {
+				got = append(got, fmt.Sprintf("%s:%d", comment.Path, comment.Line))
+			}
+			if !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected comments at %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestReviewComments_Grouping(t *testing.T) {
+	client := NewClient("test-token", "owner/repo")
+	diff := pullRequestDiff{"a.ts": {1: true, 2: true}}
+	failure := Failure{Parser: "tsc", File: "a.ts", Line: 2, Message: "TS2322: Type 'string' is not assignable"}
+
+	report := &FailureReport{Jobs: []JobReport{
+		{Failures: []Failure{failure, {Parser: "eslint", File: "a.ts", Line: 2, Message: "no-unused-vars"}}},
+		{Failures: []Failure{failure, {Parser: "tsc", File: "a.ts", Line: 1, Message: "TS1005"}}},
+	}}
+
+	comments := client.reviewComments(report, diff)
+	if len(comments) != 2 || comments[0].Line != 1 || comments[1].Line != 2 {
+		t.Fatalf("Expected comments on lines 1 and 2 in order, got %+v", comments)
+	}
+	if n := strings.Count(comments[1].Body, "TS2322"); n != 1 {
+		t.Errorf("Expected the failure reported by both jobs once, got %d times in:\n%s", n, comments[1].Body)
+	}
+	if !strings.Contains(comments[1].Body, "no-unused-vars") {
+		t.Errorf("Expected both failures on the line in one comment, got:\n%s", comments[1].Body)
+	}
+	if comments[1].Side != "RIGHT" {
+		t.Errorf("Expected comments on the new side of the diff, got %q", comments[1].Side)
+	}
+}
+
+func TestCreateFailureReview(t *testing.T) {
+	var posted Review
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/pulls/7":
+			json.NewEncoder(w).Encode(PullRequest{Number: 7, Head: Head{SHA: "abc123"}})
+		case r.Method == "GET" && r.URL.Path == "/repos/owner/repo/pulls/7/files":
+			json.NewEncoder(w).Encode([]PullRequestFile{
+				{Filename: "math/math.go", Status: "modified", Patch: samplePatch},
+				{Filename: "old.go", Status: "removed", Patch: "@@ -1 +0,0 @@\n-package old"},
+			})
+		case r.Method == "POST" && r.URL.Path == "/repos/owner/repo/pulls/7/reviews":
+			json.NewDecoder(r.Body).Decode(&posted)
+			w.WriteHeader(http.StatusOK)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-token", "owner/repo")
+	client.baseURL = srv.URL
+
+	workflow := &WorkflowRun{Name: "CI", HeadSHA: "abc123"}
+	report := &FailureReport{Jobs: []JobReport{{Failures: []Failure{
+		{Parser: "go test", TestID: "TestAdd", File: "math.go", Line: 4, Message: "Add(2, 3) = 6"},
+		{Parser: "go test", TestID: "TestSub", File: "math.go", Line: 40, Message: "outside the diff"},
+	}}}}
+
+	if err := client.createFailureReview(context.Background(), 7, workflow, report); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if posted.CommitID != "abc123" || posted.Event != "COMMENT" {
+		t.Errorf("Expected a COMMENT review on abc123, got %+v", posted)
+	}
+	if len(posted.Comments) != 1 || posted.Comments[0].Path != "math/math.go" || posted.Comments[0].Line != 4 {
+		t.Fatalf("Expected one comment on math/math.go:4, got %+v", posted.Comments)
+	}
+	if !strings.Contains(posted.Comments[0].Body, "Add(2, 3) = 6") || !strings.Contains(posted.Comments[0].Body, untrustedBegin) {
+		t.Errorf("Expected the failure marked as CI output, got:\n%s", posted.Comments[0].Body)
+	}
+}
+
+func TestCreateFailureReview_HeadMoved(t *testing.T) {
+	var requests []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "GET" && r.URL.Path == "/repos/owner/repo/pulls/7" {
+			json.NewEncoder(w).Encode(PullRequest{Number: 7, Head: Head{SHA: "def456"}})
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-token", "owner/repo")
+	client.baseURL = srv.URL
+
+	workflow := &WorkflowRun{Name: "CI", HeadSHA: "abc123"}
+	report := &FailureReport{Jobs: []JobReport{{Failures: []Failure{
+		{Parser: "go test", TestID: "TestAdd", File: "math.go", Line: 4, Message: "Add(2, 3) = 6"},
+	}}}}
+
+	if err := client.createFailureReview(context.Background(), 7, workflow, report); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("Expected only the pull request to be read once its head moved, got %v", requests)
+	}
+}
+
+func TestFitReviewCommentBody(t *testing.T) {
+	failures := []Failure{
+		{Parser: "go test", TestID: "TestAdd", Message: strings.Repeat("got a long line of output\n", 100)},
+		{Parser: "go test", TestID: "TestSub", Message: "short"},
+	}
+
+	tests := []struct {
+		name     string
+		limit    int
+		expected []string
+	}{
+		{name: "fits", limit: maxCommentLength, expected: []string{"TestAdd", "TestSub", "short"}},
+		{name: "details trimmed", limit: 1000, expected: []string{"TestAdd", "TestSub", "short", "lines omitted"}},
+		{name: "cut", limit: 200, expected: []string{"characters omitted"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := fitReviewCommentBody(failures, tt.limit)
+			if len(body) > tt.limit {
+				t.Errorf("Expected at most %d bytes, got %d", tt.limit, len(body))
+			}
+			for _, expected := range append(tt.expected, untrustedBegin, untrustedEnd) {
+				if !strings.Contains(body, expected) {
+					t.Errorf("Expected body to contain %q, got:\n%s", expected, body)
+				}
+			}
+		})
+	}
+}
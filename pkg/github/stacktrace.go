@@ -0,0 +1,464 @@
+package github
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// maxRepoFrames bounds how many repository frames are kept per stack
+	maxRepoFrames = 8
+	// maxGoroutines bounds how many distinct goroutine stacks are kept
+	maxGoroutines = 5
+	// maxHeaderLines bounds the lines between a panic and its first goroutine
+	maxHeaderLines = 10
+	// maxCapturedFrames bounds the frames buffered per goroutine or exception
+	maxCapturedFrames = 200
+	// maxCapturedSegments bounds the goroutines or causes buffered per trace
+	maxCapturedSegments = 100
+)
+
+var (
+	goPanicRegex       = regexp.MustCompile(`^(panic: |fatal error: )`)
+	goGoroutineRegex   = regexp.MustCompile(`^goroutine \d+ \[.*\]:$`)
+	goFrameFileRegex   = regexp.MustCompile(`^\t(.+?):(\d+)(?: \+0x[0-9a-f]+)?$`)
+	pythonTraceRegex   = regexp.MustCompile(`^(\s*)Traceback \(most recent call last\):$`)
+	pythonFrameRegex   = regexp.MustCompile(`^\s*File "(.+)", line (\d+), in (.+)$`)
+	javaExceptionRegex = regexp.MustCompile(`^\s*(?:Exception in thread "[^"]*" )?(?:[a-zA-Z_$][\w$]*\.)+[A-Z][\w$]*(?:Exception|Error|Throwable)[\w$]*(?::.*)?$`)
+	jvmFrameRegex      = regexp.MustCompile(`^\s+at (?:\S+//)?(\S+)\((?:(\S+?):(\d+)|[^)]*)\)$`)
+	javaCausedByRegex  = regexp.MustCompile(`^\s*(Caused by|Suppressed): `)
+	javaMoreRegex      = regexp.MustCompile(`^\s+\.\.\. \d+ more$`)
+)
+
+// nonRepoPathMarkers identify stack frame paths outside the repository
+var nonRepoPathMarkers = []string{
+	"/go/pkg/mod/", "/vendor/", "/node_modules/", "site-packages", "dist-packages",
+	"/hostedtoolcache/", "/usr/lib/", "/usr/local/go/", "/usr/local/lib/", "<frozen", "<string>",
+}
+
+// nonRepoJavaPackages identify JVM frames from the runtime and test tooling
+var nonRepoJavaPackages = []string{
+	"java.", "javax.", "jdk.", "sun.", "com.sun.", "kotlin.", "scala.",
+	"org.junit.", "junit.", "org.opentest4j.", "org.apache.maven.", "org.gradle.",
+}
+
+// isRepoPath reports whether a stack frame file lives in the repository
+func isRepoPath(path string) bool {
+	for _, marker := range nonRepoPathMarkers {
+		if strings.Contains(path, marker) {
+			return false
+		}
+	}
+	return true
+}
+
+// isRepoJavaFrame reports whether a JVM frame's method belongs to the repository
+func isRepoJavaFrame(method string) bool {
+	for _, pkg := range nonRepoJavaPackages {
+		if strings.HasPrefix(method, pkg) {
+			return false
+		}
+	}
+	return true
+}
+
+type stackTraceExtractor struct{}
+
+func (stackTraceExtractor) Name() string      { return "stack trace" }
+func (stackTraceExtractor) NewParser() Parser { return &stackParser{} }
+
+// stackFrame is one call in a stack trace, kept as its original lines
+type stackFrame struct {
+	lines []string
+	file  string
+	line  int
+	repo  bool
+}
+
+// stackSegment is one goroutine, or one exception in a chain of causes
+type stackSegment struct {
+	header  string
+	frames  []stackFrame
+	trailer string
+	// dropped counts frames beyond maxCapturedFrames
+	dropped int
+}
+
+// addFrame buffers a frame, counting the ones beyond maxCapturedFrames
+func (s *stackSegment) addFrame(frame stackFrame) {
+	if len(s.frames) >= maxCapturedFrames {
+		s.dropped++
+		return
+	}
+	s.frames = append(s.frames, frame)
+}
+
+// stackTrace is a trace being captured as a unit
+type stackTrace struct {
+	kind     string
+	message  string
+	header   []string
+	segments []stackSegment
+	// indent is the indentation of a Python "Traceback" line
+	indent string
+	// pending holds a Go function line waiting for its file line
+	pending string
+	// dropped counts segments beyond maxCapturedSegments
+	dropped int
+	// overflow receives the frames of dropped segments
+	overflow stackSegment
+}
+
+func (t *stackTrace) segment() *stackSegment {
+	if t.dropped > 0 {
+		return &t.overflow
+	}
+	if len(t.segments) == 0 {
+		t.segments = append(t.segments, stackSegment{})
+	}
+	return &t.segments[len(t.segments)-1]
+}
+
+// addSegment starts a new goroutine or cause, unless maxCapturedSegments
+// have been buffered already
+func (t *stackTrace) addSegment(header string) {
+	if len(t.segments) >= maxCapturedSegments {
+		t.dropped++
+		t.overflow = stackSegment{}
+		return
+	}
+	t.segments = append(t.segments, stackSegment{header: header})
+}
+
+// stackParser captures Go panics, Python tracebacks and JVM exceptions
+type stackParser struct {
+	failures []Failure
+	trace    *stackTrace
+	// javaHeadline is an exception line that starts a trace if frames follow
+	javaHeadline string
+}
+
+func (p *stackParser) Feed(line string) {
+	if p.trace != nil {
+		if p.continueTrace(line) {
+			return
+		}
+		p.finish()
+	}
+	p.start(line)
+}
+
+// start begins a new trace when the line opens one
+func (p *stackParser) start(line string) {
+	headline := p.javaHeadline
+	p.javaHeadline = ""
+
+	switch {
+	case goPanicRegex.MatchString(line):
+		p.trace = &stackTrace{kind: "go", message: line, header: []string{line}}
+	case strings.Contains(line, "Traceback") && pythonTraceRegex.MatchString(line):
+		m := pythonTraceRegex.FindStringSubmatch(line)
+		p.trace = &stackTrace{kind: "python", indent: m[1], header: []string{strings.TrimSpace(line)}}
+	case headline != "" && jvmFrameRegex.MatchString(line):
+		p.trace = &stackTrace{kind: "java", message: headline}
+		p.continueTrace(line)
+	case mayNameException(line) && javaExceptionRegex.MatchString(line):
+		p.javaHeadline = strings.TrimSpace(line)
+	}
+}
+
+// mayNameException reports whether a line holds one of the class name
+// suffixes javaExceptionRegex requires, which is much cheaper to check
+func mayNameException(line string) bool {
+	return strings.Contains(line, "Exception") || strings.Contains(line, "Error") || strings.Contains(line, "Throwable")
+}
+
+// continueTrace adds a line to the current trace, returning false when the
+// line does not belong to it
+func (p *stackParser) continueTrace(line string) bool {
+	t := p.trace
+	switch t.kind {
+	case "go":
+		return p.continueGo(line)
+	case "python":
+		if m := pythonFrameRegex.FindStringSubmatch(line); m != nil {
+			seg := t.segment()
+			seg.addFrame(stackFrame{
+				lines: []string{line},
+				file:  m[1],
+				line:  atoi(m[2]),
+				repo:  isRepoPath(m[1]),
+			})
+			return true
+		}
+		if strings.HasPrefix(line, t.indent+" ") {
+			// Source line of the previous frame
+			if seg := t.segment(); len(seg.frames) > 0 {
+				frame := &seg.frames[len(seg.frames)-1]
+				frame.lines = append(frame.lines, line)
+			}
+			return true
+		}
+		if strings.TrimSpace(line) != "" && t.message == "" {
+			t.message = strings.TrimSpace(line)
+		}
+		p.finish()
+		return true
+	case "java":
+		if m := jvmFrameRegex.FindStringSubmatch(line); m != nil {
+			seg := t.segment()
+			seg.addFrame(stackFrame{
+				lines: []string{line},
+				file:  m[2],
+				line:  atoi(m[3]),
+				repo:  isRepoJavaFrame(m[1]),
+			})
+			return true
+		}
+		if javaCausedByRegex.MatchString(line) {
+			t.addSegment(line)
+			return true
+		}
+		if javaMoreRegex.MatchString(line) {
+			t.segment().trailer = line
+			return true
+		}
+		return false
+	}
+	return false
+}
+
+func (p *stackParser) continueGo(line string) bool {
+	t := p.trace
+	if goGoroutineRegex.MatchString(line) {
+		t.pending = ""
+		t.addSegment(line)
+		return true
+	}
+	if len(t.segments) == 0 {
+		if len(t.header) >= maxHeaderLines {
+			return false
+		}
+		if strings.TrimSpace(line) != "" {
+			t.header = append(t.header, line)
+		}
+		return true
+	}
+
+	seg := t.segment()
+	if m := goFrameFileRegex.FindStringSubmatch(line); m != nil && t.pending != "" {
+		seg.addFrame(stackFrame{
+			lines: []string{t.pending, line},
+			file:  m[1],
+			line:  atoi(m[2]),
+			repo:  isRepoPath(m[1]),
+		})
+		t.pending = ""
+		return true
+	}
+	if strings.TrimSpace(line) == "" {
+		// Blank lines separate goroutines
+		t.pending = ""
+		return true
+	}
+	if t.pending == "" && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "exit status") &&
+		!strings.HasPrefix(line, "FAIL") && (len(seg.frames) > 0 || seg.header != "") {
+		t.pending = line
+		return true
+	}
+	return false
+}
+
+// finish turns the current trace into a failure
+func (p *stackParser) finish() {
+	t := p.trace
+	p.trace = nil
+	if t == nil || len(t.segments) == 0 || len(t.segments[0].frames) == 0 {
+		return
+	}
+
+	if len(p.failures) >= maxFailures {
+		return
+	}
+
+	f := Failure{Parser: "stack trace", Message: t.message}
+	var sb strings.Builder
+	for _, line := range t.header {
+		sb.WriteString(line + "\n")
+	}
+
+	segments, duplicates := t.segments, make([]int, len(t.segments))
+	if t.kind == "go" {
+		segments, duplicates = dedupeGoroutines(t.segments)
+	}
+	for i, seg := range segments {
+		if seg.header != "" {
+			if t.kind == "go" && sb.Len() > 0 {
+				sb.WriteString("\n")
+			}
+			sb.WriteString(seg.header + "\n")
+		}
+		for _, line := range compactFrames(seg.frames, t.kind == "python") {
+			sb.WriteString(line + "\n")
+		}
+		if seg.dropped > 0 {
+			sb.WriteString(fmt.Sprintf("\t… %d more frame(s) not captured\n", seg.dropped))
+		}
+		if seg.trailer != "" {
+			sb.WriteString(seg.trailer + "\n")
+		}
+		if duplicates[i] > 0 {
+			sb.WriteString(fmt.Sprintf("(… %d more goroutine(s) with identical stacks)\n", duplicates[i]))
+		}
+	}
+	if t.dropped > 0 {
+		sb.WriteString(fmt.Sprintf("(… %d more stack(s) not captured)\n", t.dropped))
+	}
+	if t.kind == "python" {
+		sb.WriteString(t.message + "\n")
+	}
+	f.Stack = strings.TrimRight(sb.String(), "\n")
+
+	if frame, ok := topRepoFrame(t); ok {
+		f.File, f.Line = frame.file, frame.line
+	}
+	p.failures = append(p.failures, f)
+}
+
+// dedupeGoroutines keeps the first goroutine of each distinct stack, up to
+// maxGoroutines, and counts the identical ones that were dropped
+func dedupeGoroutines(segments []stackSegment) ([]stackSegment, []int) {
+	var kept []stackSegment
+	var counts []int
+	index := make(map[string]int)
+	for _, seg := range segments {
+		var key strings.Builder
+		for _, frame := range seg.frames {
+			key.WriteString(frame.lines[0] + "\n")
+		}
+		if i, ok := index[key.String()]; ok {
+			counts[i]++
+			continue
+		}
+		if len(kept) == maxGoroutines {
+			continue
+		}
+		index[key.String()] = len(kept)
+		kept = append(kept, seg)
+		counts = append(counts, 0)
+	}
+	return kept, counts
+}
+
+// compactFrames keeps the repository frames nearest the failure and collapses
+// runs of runtime, stdlib and vendor frames into a single marker. Python lists
+// the failing call last, the other languages first.
+func compactFrames(frames []stackFrame, failingLast bool) []string {
+	repoFrames := 0
+	for _, frame := range frames {
+		if frame.repo {
+			repoFrames++
+		}
+	}
+	if repoFrames == 0 {
+		// Nothing points into the repository; keep the frames nearest the failure
+		var lines []string
+		start, end := 0, len(frames)
+		if end > 3 {
+			if failingLast {
+				start = end - 3
+			} else {
+				end = 3
+			}
+		}
+		for _, frame := range frames[start:end] {
+			lines = append(lines, frame.lines...)
+		}
+		if omitted := len(frames) - (end - start); omitted > 0 {
+			lines = append(lines, fmt.Sprintf("\t… %d more frame(s) omitted", omitted))
+		}
+		return lines
+	}
+
+	keep := make([]bool, len(frames))
+	kept := 0
+	for n := 0; n < len(frames) && kept < maxRepoFrames; n++ {
+		i := n
+		if failingLast {
+			i = len(frames) - 1 - n
+		}
+		if frames[i].repo {
+			keep[i] = true
+			kept++
+		}
+	}
+
+	var lines []string
+	omitted := 0
+	flush := func() {
+		if omitted > 0 {
+			lines = append(lines, fmt.Sprintf("\t… %d runtime/vendor frame(s) omitted", omitted))
+			omitted = 0
+		}
+	}
+	for i, frame := range frames {
+		if !keep[i] {
+			omitted++
+			continue
+		}
+		flush()
+		lines = append(lines, frame.lines...)
+	}
+	flush()
+	return lines
+}
+
+// topRepoFrame returns the repository frame nearest the failure
+func topRepoFrame(t *stackTrace) (stackFrame, bool) {
+	frames := t.segments[0].frames
+	if t.kind == "python" {
+		for i := len(frames) - 1; i >= 0; i-- {
+			if frames[i].repo && frames[i].file != "" {
+				return frames[i], true
+			}
+		}
+		return stackFrame{}, false
+	}
+	for _, frame := range frames {
+		if frame.repo && frame.file != "" {
+			return frame, true
+		}
+	}
+	return stackFrame{}, false
+}
+
+func (p *stackParser) Failures() []Failure {
+	p.finish()
+	return p.failures
+}
+
+// mergeStackTraces attaches captured stack traces to the failures of other
+// extractors that reported the same error, so each error is shown once
+func mergeStackTraces(failures []Failure) []Failure {
+	var merged []Failure
+	for _, f := range failures {
+		if f.Parser != "stack trace" {
+			merged = append(merged, f)
+			continue
+		}
+		attached := false
+		for i := range merged {
+			if merged[i].Parser != "stack trace" && f.Message != "" &&
+				strings.Contains(merged[i].Message, strings.TrimSpace(f.Message)) {
+				merged[i].Stack = f.Stack
+				attached = true
+				break
+			}
+		}
+		if !attached {
+			merged = append(merged, f)
+		}
+	}
+	return merged
+}
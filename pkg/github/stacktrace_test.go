@@ -0,0 +1,164 @@
+package github
+
+import (
+	"strings"
+	"testing"
+)
+
+const goPanicLog = `=== RUN   TestDivide
+--- FAIL: TestDivide (0.00s)
+panic: runtime error: integer divide by zero [recovered]
+	panic: runtime error: integer divide by zero
+
+goroutine 7 [running]:
+testing.tRunner.func1.2({0x5a1f20, 0x6b7a10})
+	/opt/hostedtoolcache/go/1.21.0/x64/src/testing/testing.go:1545 +0x238
+panic({0x5a1f20?, 0x6b7a10?})
+	/opt/hostedtoolcache/go/1.21.0/x64/src/runtime/panic.go:914 +0x21f
+example.com/app.Divide(...)
+	/home/runner/work/app/app/math.go:9
+example.com/app.TestDivide(0xc000007860?)
+	/home/runner/work/app/app/math_test.go:12 +0x1d
+testing.tRunner(0xc000007860, 0x5f0c48)
+	/opt/hostedtoolcache/go/1.21.0/x64/src/testing/testing.go:1595 +0xff
+created by testing.(*T).Run in goroutine 1
+	/opt/hostedtoolcache/go/1.21.0/x64/src/testing/testing.go:1648 +0x3ad
+
+goroutine 8 [chan receive]:
+example.com/app.worker()
+	/home/runner/work/app/app/worker.go:20 +0x25
+created by example.com/app.Start in goroutine 7
+	/home/runner/work/app/app/worker.go:10 +0x1a
+
+goroutine 9 [chan receive]:
+example.com/app.worker()
+	/home/runner/work/app/app/worker.go:20 +0x25
+created by example.com/app.Start in goroutine 7
+	/home/runner/work/app/app/worker.go:10 +0x1a
+exit status 2
+FAIL	example.com/app	0.012s`
+
+func TestStackParser_GoPanic(t *testing.T) {
+	failures := runParser(stackTraceExtractor{}, goPanicLog)
+	if len(failures) != 1 {
+		t.Fatalf("Expected 1 failure, got %d: %+v", len(failures), failures)
+	}
+
+	f := failures[0]
+	if f.Message != "panic: runtime error: integer divide by zero [recovered]" {
+		t.Errorf("Unexpected message: %q", f.Message)
+	}
+	if f.File != "/home/runner/work/app/app/math.go" || f.Line != 9 {
+		t.Errorf("Expected the top repository frame, got %s:%d", f.File, f.Line)
+	}
+
+	expected := `panic: runtime error: integer divide by zero [recovered]
+	panic: runtime error: integer divide by zero
+
+goroutine 7 [running]:
+	… 2 runtime/vendor frame(s) omitted
+example.com/app.Divide(...)
+	/home/runner/work/app/app/math.go:9
+example.com/app.TestDivide(0xc000007860?)
+	/home/runner/work/app/app/math_test.go:12 +0x1d
+	… 2 runtime/vendor frame(s) omitted
+
+goroutine 8 [chan receive]:
+example.com/app.worker()
+	/home/runner/work/app/app/worker.go:20 +0x25
+created by example.com/app.Start in goroutine 7
+	/home/runner/work/app/app/worker.go:10 +0x1a
+(… 1 more goroutine(s) with identical stacks)`
+	if f.Stack != expected {
+		t.Errorf("Expected stack:\n%s\n\nGot:\n%s", expected, f.Stack)
+	}
+}
+
+func TestStackParser_PythonTraceback(t *testing.T) {
+	logs := `Traceback (most recent call last):
+  File "/home/runner/work/app/app/main.py", line 12, in <module>
+    run()
+  File "/home/runner/work/app/app/app/service.py", line 30, in run
+    requests.get(url)
+  File "/opt/hostedtoolcache/Python/3.11.4/x64/lib/python3.11/site-packages/requests/api.py", line 73, in get
+    return request("get", url, params=params, **kwargs)
+  File "/opt/hostedtoolcache/Python/3.11.4/x64/lib/python3.11/site-packages/requests/api.py", line 59, in request
+    return session.request(method=method, url=url, **kwargs)
+requests.exceptions.ConnectionError: connection refused
+Error: Process completed with exit code 1.`
+
+	failures := runParser(stackTraceExtractor{}, logs)
+	if len(failures) != 1 {
+		t.Fatalf("Expected 1 failure, got %d: %+v", len(failures), failures)
+	}
+
+	f := failures[0]
+	if f.Message != "requests.exceptions.ConnectionError: connection refused" {
+		t.Errorf("Unexpected message: %q", f.Message)
+	}
+	if f.File != "/home/runner/work/app/app/app/service.py" || f.Line != 30 {
+		t.Errorf("Expected the repository frame nearest the error, got %s:%d", f.File, f.Line)
+	}
+	if !strings.Contains(f.Stack, "… 2 runtime/vendor frame(s) omitted") || strings.Contains(f.Stack, "site-packages") {
+		t.Errorf("Expected site-packages frames to be collapsed, got:\n%s", f.Stack)
+	}
+	if !strings.HasPrefix(f.Stack, "Traceback (most recent call last):") {
+		t.Errorf("Expected the whole traceback to be captured, got:\n%s", f.Stack)
+	}
+}
+
+func TestStackParser_JavaException(t *testing.T) {
+	logs := `Exception in thread "main" java.lang.IllegalStateException: boom
+	at com.example.App.start(App.java:22)
+	at java.base/java.lang.Thread.run(Thread.java:833)
+Caused by: java.io.IOException: disk full
+	at java.base/java.io.FileOutputStream.writeBytes(Native Method)
+	at com.example.Store.save(Store.java:40)
+	... 1 more
+BUILD FAILED`
+
+	failures := runParser(stackTraceExtractor{}, logs)
+	if len(failures) != 1 {
+		t.Fatalf("Expected 1 failure, got %d: %+v", len(failures), failures)
+	}
+
+	f := failures[0]
+	if f.Message != `Exception in thread "main" java.lang.IllegalStateException: boom` {
+		t.Errorf("Unexpected message: %q", f.Message)
+	}
+	if f.File != "App.java" || f.Line != 22 {
+		t.Errorf("Expected the top repository frame, got %s:%d", f.File, f.Line)
+	}
+
+	expected := `	at com.example.App.start(App.java:22)
+	… 1 runtime/vendor frame(s) omitted
+Caused by: java.io.IOException: disk full
+	… 1 runtime/vendor frame(s) omitted
+	at com.example.Store.save(Store.java:40)
+	... 1 more`
+	if f.Stack != expected {
+		t.Errorf("Expected stack:\n%s\n\nGot:\n%s", expected, f.Stack)
+	}
+}
+
+func TestStackParser_IgnoresExceptionNamesWithoutFrames(t *testing.T) {
+	logs := "Caught java.lang.IllegalStateException: expected in this test\nok"
+	if failures := runParser(stackTraceExtractor{}, logs); len(failures) != 0 {
+		t.Errorf("Expected no failures, got %+v", failures)
+	}
+}
+
+func TestRegistryAnalyze_MergesStackIntoTestFailure(t *testing.T) {
+	analysis := analyze(t, DefaultRegistry(), goPanicLog, AnalyzeOptions{Lines: 20})
+
+	if len(analysis.Failures) != 1 {
+		t.Fatalf("Expected the panic to be reported once, got %+v", analysis.Failures)
+	}
+	f := analysis.Failures[0]
+	if f.Parser != "go test" || f.TestID != "TestDivide" {
+		t.Errorf("Expected the go test failure to be kept, got %+v", f)
+	}
+	if !strings.Contains(f.Stack, "example.com/app.Divide(...)") {
+		t.Errorf("Expected the compacted stack to be attached, got:\n%s", f.Stack)
+	}
+}
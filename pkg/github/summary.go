@@ -0,0 +1,142 @@
+package github
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Actions taken for a pull request, as reported in RunSummary
+const (
+	ActionNone           = "none"
+	ActionFailureComment = "failure_comment"
+	ActionSuccessComment = "success_comment"
+)
+
+// RunSummary records what the monitor did in one run, for the Actions job
+// summary and step outputs
+type RunSummary struct {
+	// DryRun is set when nothing was written to GitHub
+	DryRun bool
+	// Workflow is the workflow run the event was about, if any
+	Workflow *WorkflowRun
+	// Skipped explains why no pull request was considered, if none was
+	Skipped string
+	// PullRequests are the pull requests considered, in order
+	PullRequests []*PullRequestSummary
+}
+
+// PullRequestSummary records what was decided and done for one pull request
+type PullRequestSummary struct {
+	Number int
+	// Copilot is whether the pull request was found to be Copilot's
+	Copilot bool
+	// Decision explains what was done, or why nothing was
+	Decision string
+	// Action is what was posted, one of the Action constants
+	Action string
+	// CommentURL links to the comment posted
+	CommentURL string
+	// Comment is the rendered comment, whether posted or, in a dry run, not
+	Comment string
+	// FailedJobs and Failures sum up what was extracted from a failed workflow
+	FailedJobs []string
+	Failures   int
+}
+
+// pullRequest returns the record of a pull request, adding it when new
+func (s *RunSummary) pullRequest(number int) *PullRequestSummary {
+	for _, pr := range s.PullRequests {
+		if pr.Number == number {
+			return pr
+		}
+	}
+	pr := &PullRequestSummary{Number: number, Action: ActionNone}
+	s.PullRequests = append(s.PullRequests, pr)
+	return pr
+}
+
+// Markdown renders the summary for the Actions job summary
+func (s *RunSummary) Markdown() string {
+	var sb strings.Builder
+	sb.WriteString("### Copilot PR monitor\n\n")
+	if s.DryRun {
+		sb.WriteString("> [!NOTE]\n> Dry run: comments, reviews and check runs were rendered but not written to GitHub.\n\n")
+	}
+	if s.Workflow != nil {
+		fmt.Fprintf(&sb, "Workflow [%s](%s) %s", s.Workflow.Name, s.Workflow.HTMLURL, s.Workflow.Status)
+		if s.Workflow.Conclusion != "" {
+			fmt.Fprintf(&sb, " with `%s`", s.Workflow.Conclusion)
+		}
+		sb.WriteString(".\n\n")
+	}
+	if len(s.PullRequests) == 0 {
+		skipped := s.Skipped
+		if skipped == "" {
+			skipped = "No pull requests were considered"
+		}
+		sb.WriteString(skipped + ".\n")
+		return sb.String()
+	}
+
+	sb.WriteString("| PR | Copilot | Decision | Failures | Comment |\n")
+	sb.WriteString("| --- | --- | --- | --- | --- |\n")
+	for _, pr := range s.PullRequests {
+		copilot := "❌"
+		if pr.Copilot {
+			copilot = "✅"
+		}
+		failures := ""
+		if len(pr.FailedJobs) > 0 {
+			failures = fmt.Sprintf("%d in %s", pr.Failures, strings.Join(pr.FailedJobs, ", "))
+		}
+		comment := ""
+		if kind := strings.TrimSuffix(pr.Action, "_comment"); pr.CommentURL != "" {
+			comment = fmt.Sprintf("[%s](%s)", kind, pr.CommentURL)
+		} else if pr.Comment != "" {
+			comment = kind + " (not posted)"
+		}
+		fmt.Fprintf(&sb, "| #%d | %s | %s | %s | %s |\n",
+			pr.Number, copilot, tableCell(pr.Decision), tableCell(failures), comment)
+	}
+	return sb.String()
+}
+
+// tableCell keeps text from breaking a markdown table row
+func tableCell(text string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(text, "\n", " "), "|", `\|`)
+}
+
+// Outputs returns the step outputs of the run:
+//
+//   - pr_numbers: the pull requests considered, comma-separated
+//   - copilot_pr_numbers: those of them that are Copilot's
+//   - action_taken: the last action taken, or "none"
+//   - comment_url: the URL of the last comment posted
+//   - failure_count: the number of failures extracted
+//   - dry_run: "true" when nothing was written to GitHub
+func (s *RunSummary) Outputs() map[string]string {
+	var prs, copilotPRs []string
+	action, commentURL, failures := ActionNone, "", 0
+	for _, pr := range s.PullRequests {
+		prs = append(prs, strconv.Itoa(pr.Number))
+		if pr.Copilot {
+			copilotPRs = append(copilotPRs, strconv.Itoa(pr.Number))
+		}
+		if pr.Action != ActionNone {
+			action = pr.Action
+		}
+		if pr.CommentURL != "" {
+			commentURL = pr.CommentURL
+		}
+		failures += pr.Failures
+	}
+	return map[string]string{
+		"pr_numbers":         strings.Join(prs, ","),
+		"copilot_pr_numbers": strings.Join(copilotPRs, ","),
+		"action_taken":       action,
+		"comment_url":        commentURL,
+		"failure_count":      strconv.Itoa(failures),
+		"dry_run":            strconv.FormatBool(s.DryRun),
+	}
+}
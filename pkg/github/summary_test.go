@@ -0,0 +1,61 @@
+package github
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunSummary(t *testing.T) {
+	summary := &RunSummary{Workflow: &WorkflowRun{Name: "CI", HTMLURL: "https://github.com/owner/repo/actions/runs/1", Status: "completed", Conclusion: "failure"}}
+	summary.pullRequest(3).Decision = "Not a Copilot PR"
+	record := summary.pullRequest(7)
+	record.Copilot = true
+	record.Decision = "Posted a failure comment"
+	record.Action = ActionFailureComment
+	record.CommentURL = "https://github.com/owner/repo/pull/7#issuecomment-1"
+	record.FailedJobs = []string{"build", "test | unit"}
+	record.Failures = 4
+
+	if summary.pullRequest(7) != record {
+		t.Error("Expected the existing record of a pull request to be returned")
+	}
+
+	markdown := summary.Markdown()
+	for _, expected := range []string{
+		"Workflow [CI](https://github.com/owner/repo/actions/runs/1) completed with `failure`.",
+		"| #3 | ❌ | Not a Copilot PR |  |  |",
+		"| #7 | ✅ | Posted a failure comment | 4 in build, test \\| unit | [failure](https://github.com/owner/repo/pull/7#issuecomment-1) |",
+	} {
+		if !strings.Contains(markdown, expected) {
+			t.Errorf("Expected summary to contain %q, got:\n%s", expected, markdown)
+		}
+	}
+
+	expected := map[string]string{
+		"pr_numbers":         "3,7",
+		"copilot_pr_numbers": "7",
+		"action_taken":       ActionFailureComment,
+		"comment_url":        "https://github.com/owner/repo/pull/7#issuecomment-1",
+		"failure_count":      "4",
+		"dry_run":            "false",
+	}
+	if outputs := summary.Outputs(); !reflect.DeepEqual(outputs, expected) {
+		t.Errorf("Expected outputs %v, got %v", expected, outputs)
+	}
+}
+
+func TestRunSummary_Skipped(t *testing.T) {
+	client := NewClient("test-token", "owner/repo")
+	event := &WorkflowRunEvent{WorkflowRun: WorkflowRun{ID: 1, Name: "CI", Status: "in_progress"}}
+	if err := client.HandleWorkflowRun(event); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	if markdown := client.Summary().Markdown(); !strings.Contains(markdown, "The workflow run is not completed.") {
+		t.Errorf("Expected the summary to say why the run was skipped, got:\n%s", markdown)
+	}
+	if outputs := client.Summary().Outputs(); outputs["action_taken"] != ActionNone || outputs["pr_numbers"] != "" {
+		t.Errorf("Expected no action and no pull requests, got %v", outputs)
+	}
+}
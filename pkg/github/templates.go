@@ -0,0 +1,184 @@
+package github
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// CommentData is the data model passed to comment templates
+type CommentData struct {
+	// Workflow is the workflow run the comment is about
+	Workflow *WorkflowRun
+	// PullRequest is the number of the pull request being commented on
+	PullRequest int
+	// Attempt is the run attempt, 1 for the first run of the workflow
+	Attempt int
+
+	// Jobs are the failed jobs. Each has the Job with its steps (see
+	// Job.FailedSteps), the structured Failures and Snippet taken from its
+	// logs, a Reproduction script for the failing step, the LogURL of the
+	// failing step in the log viewer, and Incomplete when its logs could not
+	// be read in time.
+	Jobs []JobReport
+	// Casualties are the jobs that failed or were skipped because a job they
+	// need failed, with their Conclusion
+	Casualties []Job
+	// Groups are the jobs with log failures or a snippet, with matrix legs
+	// that fail the same way grouped together
+	Groups []FailureGroup
+	// TestResults are failing tests read from test report artifacts
+	TestResults []Failure
+
+	// OmittedJobs and OmittedTestResults count content left out to fit the
+	// comment size limit
+	OmittedJobs        int
+	OmittedTestResults int
+	// Instructions is the repository guidance for this failure, in markdown
+	Instructions string
+	// Incomplete is set when any job's logs could not be read in time
+	Incomplete bool
+	// Untrusted is set when the comment includes text taken from CI output
+	Untrusted bool
+
+	// Runs are the latest run of each workflow on the head commit. They are
+	// only set for check run summaries.
+	Runs []WorkflowRun
+}
+
+// Comment kinds, each rendered by the template of the same name
+const (
+	FailureComment  = "failure"
+	SuccessComment  = "success"
+	CheckRunSummary = "check"
+)
+
+// templateExt is the file extension of comment templates
+const templateExt = ".md.tmpl"
+
+//go:embed templates/*.md.tmpl
+var defaultTemplateFS embed.FS
+
+// templateFuncs are the helpers available to comment templates. Text taken
+// from CI output should go through failure, codeBlock or inlineCode so that
+// it cannot break out of its markdown.
+var templateFuncs = template.FuncMap{
+	"failure":        formatFailure,
+	"codeBlock":      func(text string) string { return codeBlock(text, "") },
+	"inlineCode":     inlineCode,
+	"join":           strings.Join,
+	"untrustedBegin": func() string { return untrustedBegin },
+	"untrustedEnd":   func() string { return untrustedEnd },
+}
+
+// Templates holds the comment template of each kind
+type Templates struct {
+	templates map[string]*template.Template
+}
+
+// DefaultTemplates returns the built-in comment templates
+func DefaultTemplates() *Templates {
+	t, err := loadTemplates(defaultTemplateFS, "templates")
+	if err != nil {
+		panic(fmt.Sprintf("invalid built-in comment template: %v", err))
+	}
+	return t
+}
+
+// LoadTemplates returns the comment templates with the built-in ones
+// overridden by any <kind>.md.tmpl files in dir, such as failure.md.tmpl.
+// Every template is checked by rendering it with sample data, so mistakes
+// surface at startup rather than when a comment is posted.
+func LoadTemplates(dir string) (*Templates, error) {
+	return loadTemplates(os.DirFS(dir), ".")
+}
+
+func loadTemplates(fsys fs.FS, dir string) (*Templates, error) {
+	t := &Templates{templates: make(map[string]*template.Template)}
+	for _, kind := range []string{FailureComment, SuccessComment, CheckRunSummary} {
+		name := kind + templateExt
+		data, err := fs.ReadFile(fsys, filepath.ToSlash(filepath.Join(dir, name)))
+		if errors.Is(err, fs.ErrNotExist) {
+			data, err = defaultTemplateFS.ReadFile("templates/" + name)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		tmpl, err := template.New(name).Funcs(templateFuncs).Parse(string(data))
+		if err != nil {
+			return nil, err
+		}
+		if err := tmpl.Execute(io.Discard, sampleCommentData()); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		t.templates[kind] = tmpl
+	}
+	return t, nil
+}
+
+// Render renders the comment of the given kind
+func (t *Templates) Render(kind string, data *CommentData) (string, error) {
+	tmpl, ok := t.templates[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown comment kind %q", kind)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// newCommentData builds the template data for a failure report
+func newCommentData(report *FailureReport) *CommentData {
+	data := &CommentData{
+		Workflow:           report.Workflow,
+		PullRequest:        report.PRNumber,
+		Attempt:            max(report.Workflow.RunAttempt, 1),
+		Jobs:               report.Jobs,
+		Casualties:         report.Casualties,
+		Groups:             groupJobReports(report.Jobs, report.jobFingerprints()),
+		TestResults:        report.TestResults,
+		OmittedJobs:        report.OmittedJobs,
+		OmittedTestResults: report.OmittedTestResults,
+		Instructions:       report.Instructions,
+	}
+	for _, job := range report.Jobs {
+		data.Incomplete = data.Incomplete || job.Incomplete
+	}
+	data.Untrusted = len(data.TestResults) > 0 || len(data.Groups) > 0
+	return data
+}
+
+// sampleCommentData exercises every field of the data model, for
+// validating templates
+func sampleCommentData() *CommentData {
+	job := Job{
+		ID:         1,
+		Name:       "test (ubuntu-latest)",
+		Conclusion: "failure",
+		HTMLURL:    "https://github.com/owner/repo/actions/runs/1/job/1",
+		Steps:      []Step{{Name: "Run tests", Conclusion: "failure", Number: 3}},
+	}
+	failure := Failure{Parser: "go test", TestID: "TestAdd", File: "math_test.go", Line: 12, Message: "got 6, want 5"}
+	workflow := &WorkflowRun{ID: 1, Name: "CI", Conclusion: "failure", HTMLURL: "https://github.com/owner/repo/actions/runs/1", RunAttempt: 2}
+	data := newCommentData(&FailureReport{
+		Workflow:     workflow,
+		PRNumber:     1,
+		Instructions: "Run the tests locally.",
+		Jobs:         []JobReport{{Job: job, Failures: []Failure{failure}, Snippet: "FAIL", Reproduction: "go test ./...", LogURL: job.HTMLURL + "#step:3:12", Incomplete: true, OmittedFailures: 1}},
+		Casualties:   []Job{{ID: 2, Name: "deploy", Conclusion: "skipped"}},
+		TestResults:  []Failure{failure},
+		OmittedJobs:  1,
+	})
+	data.Runs = []WorkflowRun{*workflow, {ID: 2, Name: "Lint", Conclusion: "success", HTMLURL: "https://github.com/owner/repo/actions/runs/2"}}
+	return data
+}
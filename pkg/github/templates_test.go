@@ -0,0 +1,99 @@
+package github
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadTemplates(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		expectErr string
+		failure   string
+		success   string
+	}{
+		{
+			name:    "defaults when the directory is empty",
+			failure: "❌ **Workflow 'CI' failed** (attempt 2)",
+			success: "✅ **Workflow 'CI' completed successfully!**",
+		},
+		{
+			name: "override one kind",
+			files: map[string]string{
+				"success.md.tmpl": "🎉 {{.Workflow.Name}} passed on attempt {{.Attempt}} of #{{.PullRequest}}",
+			},
+			failure: "❌ **Workflow 'CI' failed**",
+			success: "🎉 CI passed on attempt 2 of #7",
+		},
+		{
+			name: "custom failure template",
+			files: map[string]string{
+				"failure.md.tmpl": "{{range .Jobs}}{{.Job.Name}}:{{range .Job.FailedSteps}} {{.Name}}{{end}}\n{{end}}",
+			},
+			failure: "build: Compile\n",
+		},
+		{
+			name:      "syntax error",
+			files:     map[string]string{"failure.md.tmpl": "{{if .Jobs}}"},
+			expectErr: "unexpected EOF",
+		},
+		{
+			name:      "unknown field",
+			files:     map[string]string{"success.md.tmpl": "{{.Workflow.Nmae}}"},
+			expectErr: "can't evaluate field Nmae",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			for name, content := range tt.files {
+				if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			templates, err := LoadTemplates(dir)
+			if tt.expectErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.expectErr) {
+					t.Fatalf("Expected error containing %q, got %v", tt.expectErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+
+			workflow := &WorkflowRun{ID: 1, Name: "CI", RunAttempt: 2}
+			job := Job{Name: "build", Steps: []Step{{Name: "Checkout", Conclusion: "success"}, {Name: "Compile", Conclusion: "failure"}}}
+			failure, err := templates.Render(FailureComment, newCommentData(&FailureReport{
+				Workflow: workflow,
+				PRNumber: 7,
+				Jobs:     []JobReport{{Job: job, Snippet: "Error: boom"}},
+			}))
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !strings.Contains(failure, tt.failure) {
+				t.Errorf("Expected failure comment to contain %q, got:\n%s", tt.failure, failure)
+			}
+
+			success, err := templates.Render(SuccessComment, &CommentData{Workflow: workflow, PullRequest: 7, Attempt: 2})
+			if err != nil {
+				t.Fatalf("Expected no error, got %v", err)
+			}
+			if !strings.Contains(success, tt.success) {
+				t.Errorf("Expected success comment to contain %q, got:\n%s", tt.success, success)
+			}
+		})
+	}
+}
+
+func TestLoadTemplates_MissingDirectory(t *testing.T) {
+	if _, err := LoadTemplates(filepath.Join(t.TempDir(), "missing")); err != nil {
+		t.Errorf("Expected the built-in templates, got error %v", err)
+	}
+}
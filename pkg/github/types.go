@@ -1,6 +1,9 @@
 package github
 
-import "time"
+import (
+	"strings"
+	"time"
+)
 
 // WorkflowRunEvent represents a workflow_run event from GitHub
 type WorkflowRunEvent struct {
@@ -19,12 +22,16 @@ type PullRequestEvent struct {
 // WorkflowRun represents a GitHub Actions workflow run
 type WorkflowRun struct {
 	ID           int64         `json:"id"`
+	WorkflowID   int64         `json:"workflow_id"`
 	Name         string        `json:"name"`
 	HeadBranch   string        `json:"head_branch"`
 	HeadSHA      string        `json:"head_sha"`
 	Status       string        `json:"status"`
 	Conclusion   string        `json:"conclusion"`
 	HTMLURL      string        `json:"html_url"`
+	Path         string        `json:"path"`
+	Event        string        `json:"event"`
+	RunAttempt   int           `json:"run_attempt"`
 	PullRequests []PullRequest `json:"pull_requests"`
 	CreatedAt    time.Time     `json:"created_at"`
 	UpdatedAt    time.Time     `json:"updated_at"`
@@ -71,12 +78,25 @@ type Owner struct {
 
 // Job represents a GitHub Actions job
 type Job struct {
-	ID         int64     `json:"id"`
-	Name       string    `json:"name"`
-	Status     string    `json:"status"`
-	Conclusion string    `json:"conclusion"`
-	StartedAt  time.Time `json:"started_at"`
-	Steps      []Step    `json:"steps"`
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	Status      string    `json:"status"`
+	Conclusion  string    `json:"conclusion"`
+	StartedAt   time.Time `json:"started_at"`
+	Steps       []Step    `json:"steps"`
+	HTMLURL     string    `json:"html_url"`
+	CheckRunURL string    `json:"check_run_url"`
+}
+
+// FailedSteps returns the steps of the job that failed
+func (j Job) FailedSteps() []Step {
+	var failed []Step
+	for _, step := range j.Steps {
+		if step.Conclusion == "failure" {
+			failed = append(failed, step)
+		}
+	}
+	return failed
 }
 
 // Step represents a step in a GitHub Actions job
@@ -87,13 +107,297 @@ type Step struct {
 	Number     int    `json:"number"`
 }
 
+// WorkflowRunsResponse represents the response from the workflow runs API
+type WorkflowRunsResponse struct {
+	TotalCount   int           `json:"total_count"`
+	WorkflowRuns []WorkflowRun `json:"workflow_runs"`
+}
+
 // JobsResponse represents the response from the jobs API
 type JobsResponse struct {
 	TotalCount int   `json:"total_count"`
 	Jobs       []Job `json:"jobs"`
 }
 
+// ContentResponse represents a file from the repository contents API
+type ContentResponse struct {
+	Content  string `json:"content"`
+	Encoding string `json:"encoding"`
+}
+
 // Comment represents a GitHub comment
 type Comment struct {
+	Body    string `json:"body"`
+	HTMLURL string `json:"html_url,omitempty"`
+}
+
+// PullRequestFile represents a file changed by a pull request
+type PullRequestFile struct {
+	Filename string `json:"filename"`
+	Status   string `json:"status"`
+	// Patch is the unified diff of the file, absent for binary or very
+	// large changes
+	Patch string `json:"patch"`
+}
+
+// Review represents a pull request review to create
+type Review struct {
+	CommitID string          `json:"commit_id"`
+	Event    string          `json:"event"`
+	Body     string          `json:"body"`
+	Comments []ReviewComment `json:"comments"`
+}
+
+// ReviewComment represents an inline comment of a pull request review
+type ReviewComment struct {
+	Path string `json:"path"`
+	Line int    `json:"line"`
+	Side string `json:"side"`
 	Body string `json:"body"`
 }
+
+// JobReport holds what was extracted from the logs of a failed job
+type JobReport struct {
+	Job      Job
+	Failures []Failure
+	Snippet  string
+	// Incomplete is set when the job's logs could not be fully read before
+	// the analysis deadline
+	Incomplete bool
+	// OmittedFailures counts failures left out to fit the comment size limit
+	OmittedFailures int
+	// Reproduction is a shell script that reruns the failing step locally,
+	// taken from the workflow definition
+	Reproduction string
+	// LogURL links to the failing step in the Actions log viewer, at its
+	// first error line when known
+	LogURL string
+}
+
+// addAnnotations records check-run annotations for the job. They take
+// precedence over the heuristic snippet and over log-parsed failures at the
+// same location.
+func (r *JobReport) addAnnotations(annotations []Failure) {
+	if len(annotations) == 0 {
+		return
+	}
+
+	failures := append([]Failure{}, annotations...)
+	for _, f := range r.Failures {
+		duplicate := false
+		for _, a := range annotations {
+			if sameLocation(a, f) {
+				duplicate = true
+				break
+			}
+		}
+		if !duplicate {
+			failures = append(failures, f)
+		}
+	}
+	r.Failures = failures
+	r.Snippet = ""
+}
+
+// sameLocation reports whether two failures point at the same file and line
+func sameLocation(a, b Failure) bool {
+	if a.Line == 0 || a.Line != b.Line || a.File == "" || b.File == "" {
+		return false
+	}
+	fa, fb := strings.TrimPrefix(a.File, "./"), strings.TrimPrefix(b.File, "./")
+	return strings.HasSuffix(fa, fb) || strings.HasSuffix(fb, fa)
+}
+
+// FailureReport holds everything gathered about a failed workflow run
+type FailureReport struct {
+	Workflow *WorkflowRun
+	PRNumber int
+	Jobs     []JobReport
+	// Casualties are jobs that failed or were skipped because a job they
+	// need failed. Jobs holds only the root-cause failures.
+	Casualties []Job
+	// TestResults holds failing tests read from test report artifacts
+	TestResults []Failure
+	// Instructions is repository guidance for Copilot on this failure
+	Instructions string
+	// OmittedTestResults and OmittedJobs count the test results and failed
+	// jobs left out to fit the comment size limit
+	OmittedTestResults int
+	OmittedJobs        int
+
+	// fingerprints caches the jobFingerprint of each job, so that a report
+	// trimmed to fit the size limit keeps the grouping of the full report
+	fingerprints []uint64
+}
+
+// failureCount returns the number of failures in the report
+func (r *FailureReport) failureCount() int {
+	count := len(r.TestResults)
+	for _, job := range r.Jobs {
+		count += len(job.Failures)
+	}
+	return count
+}
+
+// setTestResults records artifact test results. They replace any
+// log-parsed failure for the same test, and the heuristic snippet of a job
+// whose log mentions a reported test. Artifacts do not say which job
+// uploaded them, so the snippets of other failed jobs are kept.
+func (r *FailureReport) setTestResults(results []Failure) {
+	if len(results) == 0 {
+		return
+	}
+	r.TestResults = results
+
+	reported := make(map[string]bool)
+	for _, f := range results {
+		reported[f.TestID] = true
+	}
+	for i := range r.Jobs {
+		var failures []Failure
+		for _, f := range r.Jobs[i].Failures {
+			if f.TestID == "" || !reported[f.TestID] {
+				failures = append(failures, f)
+			}
+		}
+		r.Jobs[i].Failures = failures
+		if mentionsTest(r.Jobs[i].Snippet, results) {
+			r.Jobs[i].Snippet = ""
+		}
+	}
+}
+
+// mentionsTest reports whether log text names any of the given tests, by
+// its full ID or the name it ends with
+func mentionsTest(text string, tests []Failure) bool {
+	if text == "" {
+		return false
+	}
+	for _, f := range tests {
+		if f.TestID == "" {
+			continue
+		}
+		name := f.TestID
+		if i := strings.LastIndexAny(name, "./: "); i >= 0 && i < len(name)-1 {
+			name = name[i+1:]
+		}
+		if strings.Contains(text, f.TestID) || (len(name) > 3 && strings.Contains(text, name)) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasLogs reports whether any job has failures or a snippet to show
+func (r *FailureReport) hasLogs() bool {
+	for _, job := range r.Jobs {
+		if job.hasLogs() {
+			return true
+		}
+	}
+	return false
+}
+
+// mapText returns a copy of the report with fn applied to every piece of
+// text taken from CI output: test ids, files, messages, stacks and snippets
+func (r *FailureReport) mapText(fn func(string) string) *FailureReport {
+	mapFailures := func(failures []Failure) []Failure {
+		mapped := make([]Failure, len(failures))
+		for i, f := range failures {
+			f.TestID = fn(f.TestID)
+			f.File = fn(f.File)
+			f.Message = fn(f.Message)
+			f.Stack = fn(f.Stack)
+			mapped[i] = f
+		}
+		return mapped
+	}
+
+	m := *r
+	m.fingerprints = nil
+	m.TestResults = mapFailures(r.TestResults)
+	m.Jobs = make([]JobReport, len(r.Jobs))
+	for i, job := range r.Jobs {
+		job.Failures = mapFailures(job.Failures)
+		job.Snippet = fn(job.Snippet)
+		m.Jobs[i] = job
+	}
+	return &m
+}
+
+// jobFingerprints returns the fingerprint of each job, computing them once
+func (r *FailureReport) jobFingerprints() []uint64 {
+	if len(r.fingerprints) != len(r.Jobs) {
+		r.fingerprints = make([]uint64, len(r.Jobs))
+		for i, job := range r.Jobs {
+			r.fingerprints[i] = jobFingerprint(job)
+		}
+	}
+	return r.fingerprints
+}
+
+// hasLogs reports whether anything was extracted from the job's logs
+func (r *JobReport) hasLogs() bool {
+	return len(r.Failures) > 0 || r.OmittedFailures > 0 || r.Snippet != "" || r.Reproduction != ""
+}
+
+// Artifact represents a GitHub Actions workflow run artifact
+type Artifact struct {
+	ID                 int64  `json:"id"`
+	Name               string `json:"name"`
+	SizeInBytes        int64  `json:"size_in_bytes"`
+	ArchiveDownloadURL string `json:"archive_download_url"`
+	Expired            bool   `json:"expired"`
+}
+
+// ArtifactsResponse represents the response from the artifacts API
+type ArtifactsResponse struct {
+	TotalCount int        `json:"total_count"`
+	Artifacts  []Artifact `json:"artifacts"`
+}
+
+// CheckRun represents a GitHub check run
+type CheckRun struct {
+	ID      int64          `json:"id"`
+	Name    string         `json:"name"`
+	HeadSHA string         `json:"head_sha"`
+	HTMLURL string         `json:"html_url"`
+	Output  CheckRunOutput `json:"output"`
+}
+
+// CheckRunOutput represents the output of a check run
+type CheckRunOutput struct {
+	Title            string `json:"title"`
+	Summary          string `json:"summary"`
+	AnnotationsCount int    `json:"annotations_count"`
+	AnnotationsURL   string `json:"annotations_url"`
+}
+
+// Annotation represents a check run annotation
+type Annotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	StartColumn     int    `json:"start_column,omitempty"`
+	EndColumn       int    `json:"end_column,omitempty"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message"`
+	RawDetails      string `json:"raw_details,omitempty"`
+}
+
+// NewCheckRun represents a check run to create, or the update of one
+type NewCheckRun struct {
+	Name       string            `json:"name,omitempty"`
+	HeadSHA    string            `json:"head_sha,omitempty"`
+	Status     string            `json:"status,omitempty"`
+	Conclusion string            `json:"conclusion,omitempty"`
+	Output     NewCheckRunOutput `json:"output"`
+}
+
+// NewCheckRunOutput represents the output of a check run to create
+type NewCheckRunOutput struct {
+	Title       string       `json:"title"`
+	Summary     string       `json:"summary"`
+	Annotations []Annotation `json:"annotations,omitempty"`
+}
